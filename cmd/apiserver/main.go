@@ -6,6 +6,7 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
 	"log"
+	"os"
 	"rest_api/internal/app/apiserver"
 )
 
@@ -31,6 +32,14 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if flag.Arg(0) == "migrate" {
+		if err := apiserver.Migrate(config, flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
 	if err := apiserver.Start(config); err != nil {
 		log.Fatal(err)
 	}
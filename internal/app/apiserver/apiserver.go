@@ -1,23 +1,231 @@
 package apiserver
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/logging"
+	"rest_api/internal/app/migrate"
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/sqlitestore"
 	"rest_api/internal/app/store/sqlstore"
+	"rest_api/internal/app/store/teststore"
+	"rest_api/migrations"
+	"sync"
+	"syscall"
 )
 
+// Start runs the API server until it receives SIGINT or SIGTERM and then
+// shuts it down gracefully.
 func Start(config *Config) error {
-	db, err := newDB(config.DatabaseURL)
+	slog.SetDefault(logging.New(os.Stderr, config.LogLevel, config.LogFormat))
+
+	keys, err := auth.LoadKeyManager(config.SigningKeyID, config.Keys)
+	if err != nil {
+		return err
+	}
+
+	tp, err := newTracerProvider(context.Background(), config)
+	if err != nil {
+		return err
+	}
+
+	// The store starts its spans from the global provider.
+	if tp != nil {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		defer shutdownTracing(tp, config)
+	}
+
+	store, db, err := newStore(config)
 	if err != nil {
 		return err
 	}
 
-	defer db.Close()
-	store := sqlstore.New(db)
-	srv := newServer(store)
+	l, err := net.Listen("tcp", config.BindAddr)
+	if err != nil {
+		if db != nil {
+			db.Close()
+		}
+
+		return err
+	}
+
+	srv := newServer(store, keys, config)
+
+	// The readiness probe checks that the schema is up to date.
+	if db != nil {
+		srv.metrics.observeDB(db, config.DatabaseDriver)
+
+		if srv.migrator, err = migrate.New(db, migrationsFor(config.DatabaseDriver)); err != nil {
+			l.Close()
+			db.Close()
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("listening", "addr", l.Addr().String(), "database_driver", config.DatabaseDriver)
+
+	return serve(ctx, l, srv, db, config)
+}
+
+// serve answers requests on l and runs the background workers until ctx is
+// done. Shutting down, the server is marked as not ready and stops accepting
+// connections; requests in flight get config.ShutdownTimeout to finish.
+// Only then are the workers stopped and db, which may be nil, closed.
+func serve(ctx context.Context, l net.Listener, srv *server, db *sql.DB, config *Config) error {
+	httpServer := &http.Server{
+		Handler: srv,
+		ReadTimeout: config.ReadTimeout.Duration,
+		ReadHeaderTimeout: config.ReadHeaderTimeout.Duration,
+		WriteTimeout: config.WriteTimeout.Duration,
+		IdleTimeout: config.IdleTimeout.Duration,
+	}
+
+	stop := make(chan struct{})
+	workers := &sync.WaitGroup{}
+
+	for _, wk := range srv.workers {
+		if wk.disabled() {
+			slog.Info("worker disabled", "worker", wk.name)
+			continue
+		}
+
+		workers.Add(1)
+
+		go func(wk *worker) {
+			defer workers.Done()
+			wk.run(stop)
+		}(wk)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.Serve(l)
+	}()
+
+	srv.setReady(true)
+
+	var err error
+
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		slog.Info("shutting down", "shutdown_timeout", config.ShutdownTimeout.Duration.String())
+		srv.setReady(false)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout.Duration)
+		defer cancel()
+
+		if err = httpServer.Shutdown(shutdownCtx); err != nil {
+			httpServer.Close()
+		}
+	}
+
+	srv.setReady(false)
+
+	close(stop)
+	workers.Wait()
+
+	if db != nil {
+		db.Close()
+	}
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// shutdownTracing exports the spans that are still buffered. It gets as long
+// as the HTTP server had to shut down.
+func shutdownTracing(tp *sdktrace.TracerProvider, config *Config) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout.Duration)
+	defer cancel()
+
+	if err := tp.Shutdown(ctx); err != nil {
+		slog.Error("shutting down tracing", "error", err)
+	}
+}
+
+// newStore opens the database selected by config.DatabaseDriver. With
+// config.AutoMigrate the schema is brought up to date first, which lets an
+// SQLite deployment run from nothing but the binary. The "memory" driver
+// needs no database at all and returns a nil *sql.DB.
+func newStore(config *Config) (store.Store, *sql.DB, error) {
+	if config.DatabaseDriver == "memory" {
+		return teststore.New(), nil, nil
+	}
+
+	db, fsys, err := openDB(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config.AutoMigrate {
+		m, err := migrate.New(db, fsys)
+		if err == nil {
+			err = m.Up()
+		}
+
+		if err != nil && err != migrate.ErrNoChange {
+			db.Close()
+			return nil, nil, err
+		}
+	}
+
+	if config.DatabaseDriver == "sqlite" {
+		return sqlitestore.New(db), db, nil
+	}
+
+	return sqlstore.New(db), db, nil
+}
+
+// openDB opens the database selected by config.DatabaseDriver and returns the
+// migrations that belong to it.
+func openDB(config *Config) (*sql.DB, fs.FS, error) {
+	switch config.DatabaseDriver {
+	case "postgres":
+		db, err := newDB(config.DatabaseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return db, migrationsFor(config.DatabaseDriver), nil
+	case "sqlite":
+		db, err := sqlitestore.Open(config.DatabaseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return db, migrationsFor(config.DatabaseDriver), nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown database driver %q", config.DatabaseDriver)
+}
+
+// migrationsFor returns the migrations written for the database driver.
+func migrationsFor(driver string) fs.FS {
+	if driver == "sqlite" {
+		return migrations.SQLite()
+	}
 
-	return http.ListenAndServe(config.BindAddr, srv)
+	return migrations.Postgres()
 }
 
 func newDB(sqlString string) (*sql.DB, error) {
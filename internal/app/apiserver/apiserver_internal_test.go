@@ -0,0 +1,211 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"path/filepath"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"testing"
+	"time"
+)
+
+func TestNewStore(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseDriver = "sqlite"
+	config.DatabaseURL = filepath.Join(t.TempDir(), "notebook.db")
+	config.AutoMigrate = true
+
+	s, db, err := newStore(config)
+	assert.NoError(t, err)
+	assert.NoError(t, s.User().Create(context.Background(), model.TestUser(t)))
+	db.Close()
+
+	// Reopening an existing database must not run the migrations again.
+	s, db, err = newStore(config)
+	assert.NoError(t, err)
+	_, err = s.User().FindByEmail(context.Background(), model.TestUser(t).Email)
+	assert.NoError(t, err)
+	db.Close()
+
+	config.DatabaseDriver = "memory"
+	s, db, err = newStore(config)
+	assert.NoError(t, err)
+	assert.Nil(t, db)
+	assert.NoError(t, s.User().Create(context.Background(), model.TestUser(t)))
+
+	config.DatabaseDriver = "mysql"
+	_, _, err = newStore(config)
+	assert.Error(t, err)
+}
+
+func TestMigrate(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseDriver = "sqlite"
+	config.DatabaseURL = filepath.Join(t.TempDir(), "notebook.db")
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, Migrate(config, []string{"status"}, out))
+	assert.Contains(t, out.String(), "version 0")
+	assert.Contains(t, out.String(), "pending")
+
+	out.Reset()
+	assert.NoError(t, Migrate(config, []string{"up"}, out))
+	assert.NoError(t, Migrate(config, []string{"up"}, out))
+	assert.Contains(t, out.String(), "no change")
+
+	out.Reset()
+	assert.NoError(t, Migrate(config, []string{"status"}, out))
+	assert.NotContains(t, out.String(), "pending")
+
+	assert.NoError(t, Migrate(config, []string{"goto", "0"}, out))
+	assert.Equal(t, errMigrateUsage, Migrate(config, []string{"goto"}, out))
+	assert.Equal(t, errMigrateUsage, Migrate(config, []string{"sideways"}, out))
+}
+
+func TestServe_GracefulShutdown(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseDriver = "sqlite"
+	config.DatabaseURL = filepath.Join(t.TempDir(), "notebook.db")
+	config.AutoMigrate = true
+
+	st, db, err := newStore(config)
+	assert.NoError(t, err)
+
+	srv := newServer(st, auth.TestKeyManager(t), config)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	srv.router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, l, srv, db, config)
+	}()
+
+	codes := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String() + "/slow")
+		if err != nil {
+			codes <- 0
+			return
+		}
+
+		resp.Body.Close()
+		codes <- resp.StatusCode
+	}()
+
+	<-entered
+	assert.True(t, srv.isReady())
+	assert.Eventually(t, func() bool {
+		return srv.workers[0].status().Running
+	}, time.Second, 10 * time.Millisecond)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		return !srv.isReady()
+	}, time.Second, 10 * time.Millisecond)
+
+	// The request in flight is drained before serve returns.
+	select {
+	case <-served:
+		t.Fatal("serve returned before the request in flight finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = net.Dial("tcp", l.Addr().String())
+	assert.Error(t, err)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-codes)
+	assert.NoError(t, <-served)
+	assert.Error(t, db.Ping())
+	assert.False(t, srv.workers[0].status().Running)
+}
+
+func TestServe_ShutdownTimeout(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseDriver = "memory"
+	config.ShutdownTimeout = Duration{10 * time.Millisecond}
+
+	st, _, err := newStore(config)
+	assert.NoError(t, err)
+
+	srv := newServer(st, auth.TestKeyManager(t), config)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv.router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, l, srv, nil, config)
+	}()
+
+	go http.Get("http://" + l.Addr().String() + "/slow")
+
+	<-entered
+	cancel()
+	assert.Equal(t, context.DeadlineExceeded, <-served)
+}
+
+func TestServe_DisabledWorkers(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseDriver = "memory"
+	config.PublishInterval = Duration{}
+	config.PurgeInterval = Duration{-time.Second}
+
+	st, _, err := newStore(config)
+	assert.NoError(t, err)
+
+	srv := newServer(st, auth.TestKeyManager(t), config)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, l, srv, nil, config)
+	}()
+
+	assert.Eventually(t, srv.isReady, time.Second, 10 * time.Millisecond)
+
+	resp, err := http.Get("http://" + l.Addr().String() + "/readyz")
+	if assert.NoError(t, err) {
+		r := &readiness{}
+		json.NewDecoder(resp.Body).Decode(r)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		if assert.Len(t, r.Workers, 2) {
+			for _, wk := range r.Workers {
+				assert.True(t, wk.Disabled, wk.Name)
+				assert.False(t, wk.Running, wk.Name)
+			}
+		}
+	}
+
+	cancel()
+	assert.NoError(t, <-served)
+}
@@ -1,13 +1,98 @@
 package apiserver
 
+import (
+	"log/slog"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/logging"
+	"time"
+)
 
 type Config struct {
 	BindAddr string `toml:"bind_addr"`
+	LogLevel slog.Level `toml:"log_level"`
+	LogFormat logging.Format `toml:"log_format"`
+	ReadTimeout Duration `toml:"read_timeout"`
+	ReadHeaderTimeout Duration `toml:"read_header_timeout"`
+	WriteTimeout Duration `toml:"write_timeout"`
+	IdleTimeout Duration `toml:"idle_timeout"`
+	ShutdownTimeout Duration `toml:"shutdown_timeout"`
+	DatabaseDriver string `toml:"database_driver"`
 	DatabaseURL string `toml:"database_url"`
+	DatabaseTimeout Duration `toml:"database_timeout"`
+	MaxBodySize int64 `toml:"max_body_size"`
+	HealthCheckTimeout Duration `toml:"health_check_timeout"`
+	ServiceName string `toml:"service_name"`
+	TracingExporter string `toml:"tracing_exporter"`
+	OTLPEndpoint string `toml:"otlp_endpoint"`
+	TraceSampleRatio float64 `toml:"trace_sample_ratio"`
+	RateLimits map[string]RateLimit `toml:"rate_limits"`
+	AutoMigrate bool `toml:"auto_migrate"`
+	AccessTokenTTL Duration `toml:"access_token_ttl"`
+	RefreshTokenTTL Duration `toml:"refresh_token_ttl"`
+	SigningKeyID string `toml:"signing_key_id"`
+	Keys []auth.KeyConfig `toml:"keys"`
+	PublishInterval Duration `toml:"publish_interval"`
+	TrashRetention Duration `toml:"trash_retention"`
+	PurgeInterval Duration `toml:"purge_interval"`
 }
 
 func NewConfig() *Config {
 	return &Config{
 		BindAddr: ":8080",
+		LogLevel: slog.LevelInfo,
+		LogFormat: logging.FormatJSON,
+		ReadTimeout: Duration{15 * time.Second},
+		ReadHeaderTimeout: Duration{5 * time.Second},
+		WriteTimeout: Duration{30 * time.Second},
+		IdleTimeout: Duration{2 * time.Minute},
+		ShutdownTimeout: Duration{30 * time.Second},
+		DatabaseDriver: "postgres",
+		DatabaseTimeout: Duration{5 * time.Second},
+		MaxBodySize: 1 << 20,
+		HealthCheckTimeout: Duration{2 * time.Second},
+		ServiceName: "rest_api",
+		OTLPEndpoint: "http://localhost:4318",
+		TraceSampleRatio: 1,
+		RateLimits: map[string]RateLimit{
+			rateLimitAuthorize: {Rate: 5, Period: Duration{time.Minute}, Burst: 5, Key: rateLimitByIP},
+			rateLimitCreate: {Rate: 10, Period: Duration{time.Hour}, Burst: 10, Key: rateLimitByIP},
+			rateLimitPublic: {Rate: 300, Period: Duration{time.Minute}, Burst: 100, Key: rateLimitByIP},
+			rateLimitPrivate: {Rate: 120, Period: Duration{time.Minute}, Burst: 60, Key: rateLimitByUser},
+			rateLimitToken: {Rate: 60, Period: Duration{time.Minute}, Burst: 20, Key: rateLimitByIP},
+		},
+		AccessTokenTTL: Duration{15 * time.Minute},
+		RefreshTokenTTL: Duration{30 * 24 * time.Hour},
+		PublishInterval: Duration{time.Minute},
+		TrashRetention: Duration{30 * 24 * time.Hour},
+		PurgeInterval: Duration{time.Hour},
 	}
 }
+
+// RateLimit is the token bucket policy of a group of routes: Burst requests
+// at once, refilled at Rate requests per Period. Key is "ip" to count the
+// requests of each client address or "user" to count those of each
+// authenticated user; requests without a valid token are counted by address.
+// A zero Rate turns limiting off for the group.
+type RateLimit struct {
+	Rate int `toml:"rate"`
+	Period Duration `toml:"period"`
+	Burst int `toml:"burst"`
+	Key string `toml:"key"`
+}
+
+// Duration allows time.Duration values to be written as strings such as
+// "15m" or "720h" in the toml config.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	d.Duration = v
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"rest_api/internal/app/logging"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/search"
+	"rest_api/internal/app/store"
+)
+
+// requestError is an error the handlers report themselves rather than get
+// from the store. Status is the HTTP status it is answered with and Code the
+// machine readable code sent along; Err is shown to the client.
+type requestError struct {
+	Status int
+	Code string
+	Err error
+}
+
+func (e *requestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.Err
+}
+
+// badRequest reports a request that could not be understood, such as
+// malformed JSON or a query parameter of the wrong type.
+func badRequest(err error) error {
+	return &requestError{http.StatusBadRequest, "bad_request", err}
+}
+
+// unauthorized reports missing or invalid credentials.
+func unauthorized(code string, message string) error {
+	return &requestError{http.StatusUnauthorized, code, errors.New(message)}
+}
+
+// forbidden reports an authenticated user acting outside their rights.
+func forbidden(message string) error {
+	return &requestError{http.StatusForbidden, "forbidden", errors.New(message)}
+}
+
+// invalid reports request data that failed validation.
+func invalid(err error) error {
+	return store.NewValidationError(err)
+}
+
+// invalidField is invalid for a value that was checked on its own, so that
+// the client still learns which field of the request was wrong.
+func invalidField(field string, err error) error {
+	message := err.Error()
+
+	var v *store.ValidationError
+	if errors.As(store.NewValidationError(err), &v) && len(v.Fields) == 1 {
+		for _, m := range v.Fields {
+			message = m
+		}
+	}
+
+	return &store.ValidationError{Message: "validation failed", Fields: map[string]string{field: message}, Err: err}
+}
+
+// invalidRequest is invalid for a model that was built from a request whose
+// fields are named differently. names maps the model's field names to the
+// request's.
+func invalidRequest(err error, names map[string]string) error {
+	err = store.NewValidationError(err)
+
+	var v *store.ValidationError
+	if !errors.As(err, &v) || len(v.Fields) == 0 {
+		return err
+	}
+
+	fields := make(map[string]string, len(v.Fields))
+	for name, message := range v.Fields {
+		if renamed, ok := names[name]; ok {
+			name = renamed
+		}
+
+		fields[name] = message
+	}
+
+	return &store.ValidationError{Message: v.Message, Fields: fields, Err: v.Err}
+}
+
+// errorCodes gives the errors that clients may want to tell apart from others
+// of their kind a code of their own.
+var errorCodes = map[error]string{
+	store.ErrEditConflict: "edit_conflict",
+	store.ErrAlreadyExists: "already_exists",
+	store.ErrInvalidReference: "invalid_reference",
+	model.ErrInvalidTransition: "invalid_transition",
+	model.ErrInvalidVisibility: "invalid_visibility",
+	model.ErrInvalidStatus: "invalid_status",
+	search.ErrEmptyQuery: "empty_query",
+}
+
+// problem is an RFC 7807 problem details object. Code is a stable, machine
+// readable name for the error and Errors holds the per-field details of a
+// validation error.
+type problem struct {
+	Type string `json:"type"`
+	Title string `json:"title"`
+	Status int `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code string `json:"code"`
+	Errors map[string]string `json:"errors,omitempty"`
+	CurrentVersion int `json:"current_version,omitempty"`
+}
+
+// problemFor maps an error to the response it is answered with. This is the
+// only place that decides on the HTTP status of an error. Errors that are not
+// of a known kind are internal; their text may come from the database driver
+// and is never sent.
+func problemFor(err error) *problem {
+	var (
+		reqErr *requestError
+		notFound *store.NotFoundError
+		conflict *store.ConflictError
+		validation *store.ValidationError
+	)
+
+	switch {
+	case errors.As(err, &reqErr):
+		return newProblem(reqErr.Status, codeFor(err, reqErr.Code), reqErr.Error())
+	case errors.As(err, &notFound):
+		return newProblem(http.StatusNotFound, codeFor(err, "not_found"), notFound.Error())
+	case errors.As(err, &conflict):
+		return newProblem(http.StatusConflict, codeFor(err, "conflict"), conflict.Error())
+	case errors.As(err, &validation):
+		p := newProblem(http.StatusUnprocessableEntity, codeFor(err, "validation_failed"), validation.Error())
+		p.Errors = validation.Fields
+		return p
+	}
+
+	return newProblem(http.StatusInternalServerError, "internal_error", "internal server error")
+}
+
+func newProblem(status int, code string, detail string) *problem {
+	return &problem{
+		Type: "about:blank",
+		Title: http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code: code,
+	}
+}
+
+func codeFor(err error, fallback string) string {
+	for target, code := range errorCodes {
+		if errors.Is(err, target) {
+			return code
+		}
+	}
+
+	return fallback
+}
+
+// error answers the request with the problem for err. Internal errors are
+// logged, since their details are kept from the client.
+func (s *server) error(w http.ResponseWriter, r *http.Request, err error) {
+	// Only the error itself is consulted: a request that ran out of time may
+	// still have failed for a reason of its own, such as a missing record.
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errDatabaseTimeout
+	}
+
+	p := problemFor(err)
+	if p.Status == http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Error("internal error", "error", err)
+	}
+
+	s.respondProblem(w, r, p)
+}
+
+func (s *server) respondProblem(w http.ResponseWriter, r *http.Request, p *problem) {
+	p.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	s.respond(w, r, p.Status, p)
+}
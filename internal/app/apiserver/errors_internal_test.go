@@ -0,0 +1,106 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+func TestServer_ProblemDetails(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	p := &problem{}
+
+	rec := testRequest(s, http.MethodPost, "/create", "", map[string]interface{}{
+		"name": "User",
+		"email": "invalid",
+		"password": "123456",
+	})
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusUnprocessableEntity, p.Status)
+	assert.Equal(t, "validation_failed", p.Code)
+	assert.Equal(t, "/create", p.Instance)
+	assert.Contains(t, p.Errors, "email")
+	assert.NotContains(t, p.Errors, "name")
+
+	testUserToken(t, s, "user@mail.com")
+
+	p = &problem{}
+	rec = testRequest(s, http.MethodPost, "/create", "", map[string]interface{}{
+		"name": "Other",
+		"email": "user@mail.com",
+		"password": "123456",
+	})
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "already_exists", p.Code)
+
+	p = &problem{}
+	rec = testRequest(s, http.MethodGet, "/articles/100", "", nil)
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "not_found", p.Code)
+
+	p = &problem{}
+	rec = testRequest(s, http.MethodGet, "/articles?status=unknown", "", nil)
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_status", p.Code)
+}
+
+func TestServer_ErrorAfterDeadline(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/articles/1", nil)
+	rec := httptest.NewRecorder()
+	s.error(rec, req, store.ErrRecordNotFound)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.error(rec, req, fmt.Errorf("find article: %w", ctx.Err()))
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
+
+func TestProblemFor(t *testing.T) {
+	testCases := []struct {
+		name string
+		err error
+		expectedStatus int
+		expectedCode string
+	}{
+		{"not found", store.ErrRecordNotFound, http.StatusNotFound, "not_found"},
+		{"edit conflict", store.ErrEditConflict, http.StatusConflict, "edit_conflict"},
+		{"invalid reference", store.ErrInvalidReference, http.StatusUnprocessableEntity, "invalid_reference"},
+		{"model error", invalid(model.ErrInvalidTransition), http.StatusUnprocessableEntity, "invalid_transition"},
+		{"request error", errMissingToken, http.StatusUnauthorized, "missing_token"},
+		{"forbidden", errForbidden, http.StatusForbidden, "forbidden"},
+		{"internal", errors.New("pq: relation \"users\" does not exist"), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := problemFor(tc.err)
+			assert.Equal(t, tc.expectedStatus, p.Status)
+			assert.Equal(t, tc.expectedCode, p.Code)
+			assert.Equal(t, http.StatusText(tc.expectedStatus), p.Title)
+		})
+	}
+
+	p := problemFor(errors.New("pq: relation \"users\" does not exist"))
+	assert.NotContains(t, p.Detail, "pq")
+}
@@ -0,0 +1,117 @@
+package apiserver
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"net/http"
+	"rest_api/internal/app/model"
+	"strconv"
+	"strings"
+)
+
+var (
+	errPreconditionRequired = &requestError{http.StatusPreconditionRequired, "precondition_required", errors.New("If-Match header or version is required")}
+	errInvalidIfMatch = badRequest(errors.New("If-Match must be a version ETag"))
+	errVersionConflict = &requestError{http.StatusPreconditionFailed, "version_conflict", errors.New("article was changed by someone else")}
+)
+
+// articleETag derives a strong ETag from the article version, which is bumped
+// on every write.
+func articleETag(a *model.Article) string {
+	return fmt.Sprintf(`"%d"`, a.Version)
+}
+
+// articlesETag derives a weak ETag for a list of articles from their IDs and
+// versions.
+func articlesETag(ars []*model.Article) string {
+	h := sha1.New()
+	for _, a := range ars {
+		fmt.Fprintf(h, "%d:%d;", a.ID, a.Version)
+	}
+
+	return fmt.Sprintf(`W/"%x"`, h.Sum(nil))
+}
+
+// requestedVersion returns the article version a write is based on. It is
+// taken from If-Match, or from the version field of the body when the header
+// is absent. "If-Match: *" accepts whatever version is current.
+func requestedVersion(r *http.Request, bodyVersion int, current int) (int, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if bodyVersion == 0 {
+			return 0, errPreconditionRequired
+		}
+
+		return bodyVersion, nil
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return current, nil
+		}
+
+		if v, err := strconv.Atoi(strings.Trim(tag, `"`)); err == nil && strings.HasPrefix(tag, `"`) {
+			return v, nil
+		}
+	}
+
+	return 0, errInvalidIfMatch
+}
+
+// notModified answers a conditional GET with 304 when If-None-Match matches
+// the current ETag, using the weak comparison RFC 7232 requires for it.
+func (s *server) notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			w.Header().Set("ETag", etag)
+			s.respond(w, r, http.StatusNotModified, nil)
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkVersion validates the client's precondition against the current
+// article and writes the error response if it fails.
+func (s *server) checkVersion(w http.ResponseWriter, r *http.Request, a *model.Article, bodyVersion int) (int, bool) {
+	version, err := requestedVersion(r, bodyVersion, a.Version)
+	if err != nil {
+		s.error(w, r, err)
+		return 0, false
+	}
+
+	if version != a.Version {
+		s.versionConflict(w, r, a.Version)
+		return 0, false
+	}
+
+	return version, true
+}
+
+func (s *server) versionConflict(w http.ResponseWriter, r *http.Request, current int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, current))
+
+	p := problemFor(errVersionConflict)
+	p.CurrentVersion = current
+	s.respondProblem(w, r, p)
+}
+
+// writeConflict reports a write that lost a race after checkVersion passed.
+func (s *server) writeConflict(w http.ResponseWriter, r *http.Request, id int) {
+	a, err := s.store.Article().Find(r.Context(), id)
+	if err != nil {
+		s.error(w, r, err)
+		return
+	}
+
+	s.versionConflict(w, r, a.Version)
+}
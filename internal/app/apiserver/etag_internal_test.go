@@ -0,0 +1,133 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+func TestServer_ArticleETag(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+
+	article := model.TestArticle(t, u.ID)
+	ts.Article().CreateArticle(context.Background(), article)
+
+	url := fmt.Sprintf("/articles/%d", article.ID)
+
+	rec := testRequest(s, http.MethodGet, url, "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("If-None-Match", `"1"`)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = testRequest(s, http.MethodGet, "/articles/100", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	change := func(ifMatch string) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]interface{}{
+			"id": article.ID,
+			"article_header": "Changed",
+			"article_text": "Changed text",
+		})
+		req, _ := http.NewRequest(http.MethodPut, "/private/change/article", b)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec = change(`"1"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	rec = change(`"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	conflict := &struct {
+		Code string `json:"code"`
+		CurrentVersion int `json:"current_version"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(conflict)
+	assert.Equal(t, "version_conflict", conflict.Code)
+	assert.Equal(t, 2, conflict.CurrentVersion)
+
+	rec = change("1")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = change("*")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+}
+
+func TestServer_ArticleETagAfterTagRename(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+
+	article := model.TestArticle(t, u.ID)
+	ts.Article().CreateArticle(context.Background(), article)
+	ts.Tag().SetArticleTags(context.Background(), article.ID, u.ID, []string{"job"})
+	tags, _ := ts.Tag().FindByOwner(context.Background(), u.ID)
+
+	url := fmt.Sprintf("/articles/%d", article.ID)
+
+	rec := testRequest(s, http.MethodGet, url, "", nil)
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(s, http.MethodPut, fmt.Sprintf("/private/tags/%d", tags[0].ID), token, map[string]interface{}{"name": "work"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), `"work"`)
+}
+
+func TestServer_ShowAllArticlesETag(t *testing.T) {
+	ts := teststore.New()
+	testAuthors(t, ts, 1)
+	ts.Article().CreateArticle(context.Background(), model.TestArticle(t, 1))
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+
+	rec := testRequest(s, http.MethodGet, "/show_all_articles", "", nil)
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, etag)
+
+	req, _ := http.NewRequest(http.MethodGet, "/show_all_articles", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	ts.Article().CreateArticle(context.Background(), model.TestArticle(t, 1))
+
+	req, _ = http.NewRequest(http.MethodGet, "/show_all_articles", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,102 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"rest_api/internal/app/logging"
+)
+
+const (
+	healthOK = "ok"
+	healthUnavailable = "unavailable"
+	healthPending = "pending"
+	healthDirty = "dirty"
+	healthDegraded = "degraded"
+	healthShuttingDown = "shutting_down"
+)
+
+// handleHealthz answers the liveness probe. It does not look at any
+// dependency: a process that answers is alive.
+func (s *server) handleHealthz() http.HandlerFunc {
+	type response struct {
+		Status string `json:"status"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, r, http.StatusOK, &response{Status: healthOK})
+	}
+}
+
+// handleReadyz answers the readiness probe. The instance is ready when the
+// database answers within the health check timeout and its schema is at the
+// latest migration. The background workers are reported but do not make the
+// instance unready. Errors are logged rather than sent.
+func (s *server) handleReadyz() http.HandlerFunc {
+	type check struct {
+		Status string `json:"status"`
+		Version int64 `json:"version,omitempty"`
+		Latest int64 `json:"latest,omitempty"`
+	}
+
+	type response struct {
+		Status string `json:"status"`
+		Database *check `json:"database"`
+		Migrations *check `json:"migrations,omitempty"`
+		Workers []*workerStatus `json:"workers"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if s.healthCheckTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.healthCheckTimeout)
+			defer cancel()
+		}
+
+		resp := &response{
+			Status: healthOK,
+			Database: &check{Status: healthOK},
+			Workers: make([]*workerStatus, 0, len(s.workers)),
+		}
+
+		if err := s.store.Ping(ctx); err != nil {
+			logging.FromContext(ctx).Warn("readiness: database unavailable", "error", err)
+			resp.Database.Status = healthUnavailable
+		}
+
+		// The memory store has no schema to migrate.
+		if s.migrator != nil {
+			resp.Migrations = &check{Status: healthOK, Latest: s.migrator.Latest()}
+
+			version, dirty, err := s.migrator.CurrentVersion(ctx)
+			switch {
+			case err != nil:
+				logging.FromContext(ctx).Warn("readiness: migration version unavailable", "error", err)
+				resp.Migrations.Status = healthUnavailable
+			case dirty:
+				resp.Migrations.Status = healthDirty
+			case version < resp.Migrations.Latest:
+				resp.Migrations.Status = healthPending
+			}
+
+			resp.Migrations.Version = version
+		}
+
+		for _, wk := range s.workers {
+			resp.Workers = append(resp.Workers, wk.status())
+		}
+
+		code := http.StatusOK
+
+		switch {
+		case !s.isReady():
+			resp.Status = healthShuttingDown
+			code = http.StatusServiceUnavailable
+		case resp.Database.Status != healthOK || resp.Migrations != nil && resp.Migrations.Status != healthOK:
+			resp.Status = healthDegraded
+			code = http.StatusServiceUnavailable
+		}
+
+		s.respond(w, r, code, resp)
+	}
+}
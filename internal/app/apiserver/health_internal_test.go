@@ -0,0 +1,114 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"path/filepath"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/migrate"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+type readiness struct {
+	Status string `json:"status"`
+	Database struct {
+		Status string `json:"status"`
+	} `json:"database"`
+	Migrations *struct {
+		Status string `json:"status"`
+		Version int64 `json:"version"`
+		Latest int64 `json:"latest"`
+	} `json:"migrations"`
+	Workers []*workerStatus `json:"workers"`
+}
+
+func TestServer_Healthz(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	rec := testRequest(s, http.MethodGet, "/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"ok"`)
+}
+
+func TestServer_Readyz(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	// Not ready until serve says so.
+	resp := &readiness{}
+	rec := testRequest(s, http.MethodGet, "/readyz", "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "shutting_down", resp.Status)
+
+	s.setReady(true)
+
+	resp = &readiness{}
+	rec = testRequest(s, http.MethodGet, "/readyz", "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "ok", resp.Database.Status)
+	assert.Nil(t, resp.Migrations)
+	assert.Len(t, resp.Workers, 2)
+	assert.Equal(t, "publisher", resp.Workers[0].Name)
+}
+
+func TestServer_ReadyzDatabase(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseDriver = "sqlite"
+	config.DatabaseURL = filepath.Join(t.TempDir(), "notebook.db")
+	// The sqlite driver may interrupt whatever runs next on its single
+	// connection when a finished query's context is cancelled, which
+	// would break the migrations run below.
+	config.DatabaseTimeout = Duration{}
+	config.HealthCheckTimeout = Duration{}
+
+	st, db, err := newStore(config)
+	assert.NoError(t, err)
+
+	m, err := migrate.New(db, migrationsFor(config.DatabaseDriver))
+	assert.NoError(t, err)
+
+	s := newServer(st, auth.TestKeyManager(t), config)
+	s.migrator = m
+	s.setReady(true)
+
+	// schema_migrations does not exist yet.
+	resp := &readiness{}
+	rec := testRequest(s, http.MethodGet, "/readyz", "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "ok", resp.Database.Status)
+	assert.Equal(t, "unavailable", resp.Migrations.Status)
+
+	// Creates schema_migrations at version 0.
+	_, _, err = m.Version()
+	assert.NoError(t, err)
+
+	resp = &readiness{}
+	rec = testRequest(s, http.MethodGet, "/readyz", "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "pending", resp.Migrations.Status)
+
+	assert.NoError(t, m.Up())
+
+	resp = &readiness{}
+	rec = testRequest(s, http.MethodGet, "/readyz", "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", resp.Migrations.Status)
+	assert.Equal(t, m.Latest(), resp.Migrations.Version)
+
+	db.Close()
+
+	resp = &readiness{}
+	rec = testRequest(s, http.MethodGet, "/readyz", "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "unavailable", resp.Database.Status)
+}
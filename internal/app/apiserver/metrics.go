@@ -0,0 +1,148 @@
+package apiserver
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// routeUnmatched labels requests that no route matched, so that scans of
+// random paths do not create a series each.
+const routeUnmatched = "unmatched"
+
+// metrics holds the registry served at /metrics and the HTTP collectors. Each
+// server has a registry of its own rather than using the global one.
+type metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "notebook",
+			Subsystem: "http",
+			Name: "requests_total",
+			Help: "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "notebook",
+			Subsystem: "http",
+			Name: "request_duration_seconds",
+			Help: "Time taken to answer HTTP requests by route template and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "notebook",
+			Subsystem: "http",
+			Name: "response_size_bytes",
+			Help: "Size of HTTP response bodies by route template and method.",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+		}, []string{"route", "method"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "notebook",
+			Subsystem: "http",
+			Name: "requests_in_flight",
+			Help: "HTTP requests being answered.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.responseSize,
+		m.inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// observeDB exports the connection pool statistics of db.
+func (m *metrics) observeDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// instrument records every request answered by the router. Requests are
+// labelled with the template of the route they match, such as
+// /articles/{id:[0-9]+}, never with the raw path.
+func (s *server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		s.metrics.inFlight.Inc()
+		defer s.metrics.inFlight.Dec()
+
+		route := routeTemplate(r)
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		s.metrics.requests.WithLabelValues(route, r.Method, strconv.Itoa(rw.status)).Inc()
+		s.metrics.duration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		s.metrics.responseSize.WithLabelValues(route, r.Method).Observe(float64(rw.size))
+	})
+}
+
+// matchRoute looks up the template of the route the request matches, or
+// routeUnmatched, and keeps it on the request context. It is the outermost
+// handler, so that the handlers wrapping the router, which run before mux has
+// matched the request, do not each match it again.
+func (s *server) matchRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeUnmatched
+
+		match := &mux.RouteMatch{}
+		if s.router.Match(r, match) && match.Route != nil {
+			if tpl, err := match.Route.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyRoute, route)))
+	})
+}
+
+// routeTemplate returns the route template matchRoute found for r.
+func routeTemplate(r *http.Request) string {
+	if route, ok := r.Context().Value(ctxKeyRoute).(string); ok {
+		return route
+	}
+
+	return routeUnmatched
+}
+
+// statusRecorder remembers the status code and counts the bytes of the
+// response it passes on.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size int
+}
+
+func (rw *statusRecorder) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *statusRecorder) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+
+	return n, err
+}
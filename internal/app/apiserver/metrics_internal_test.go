@@ -0,0 +1,82 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	testAuthors(t, ts, 1)
+
+	a := model.TestArticle(t, 1)
+	ts.Article().CreateArticle(context.Background(), a)
+
+	testRequest(s, http.MethodGet, fmt.Sprintf("/articles/%d", a.ID), "", nil)
+	testRequest(s, http.MethodGet, "/articles/100", "", nil)
+	testRequest(s, http.MethodGet, "/no/such/path", "", nil)
+	_, token := testUserToken(t, s, "user@mail.com")
+	testRequest(s, http.MethodGet, "/private/notebooks", token, nil)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.requests.WithLabelValues("/articles/{id:[0-9]+}", "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.requests.WithLabelValues("/articles/{id:[0-9]+}", "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.requests.WithLabelValues(routeUnmatched, "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.requests.WithLabelValues("/private/notebooks", "GET", "200")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(s.metrics.inFlight))
+
+	rec := testRequest(s, http.MethodGet, "/metrics", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `notebook_http_request_duration_seconds_bucket{method="GET",route="/articles/{id:[0-9]+}"`)
+	assert.Contains(t, body, `notebook_http_response_size_bytes_count{method="GET",route="/articles/{id:[0-9]+}"} 2`)
+	assert.Contains(t, body, "notebook_http_requests_in_flight 1")
+	assert.Contains(t, body, `notebook_store_calls_total{method="Find",repository="article",result="not_found"} 1`)
+	assert.NotContains(t, body, `route="/articles/100"`)
+	assert.NotContains(t, body, `route="/no/such/path"`)
+}
+
+func TestServer_MatchRoute(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	var routes []string
+	h := s.matchRoute(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routes = append(routes, routeTemplate(r))
+	}))
+
+	for _, path := range []string{"/articles/7", "/no/such/path"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, []string{"/articles/{id:[0-9]+}", routeUnmatched}, routes)
+
+	req, _ := http.NewRequest(http.MethodGet, "/articles/7", nil)
+	assert.Equal(t, routeUnmatched, routeTemplate(req))
+}
+
+func TestServer_MetricsDB(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseDriver = "sqlite"
+	config.DatabaseURL = filepath.Join(t.TempDir(), "notebook.db")
+
+	st, db, err := newStore(config)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := newServer(st, auth.TestKeyManager(t), config)
+	s.metrics.observeDB(db, config.DatabaseDriver)
+
+	rec := testRequest(s, http.MethodGet, "/metrics", "", nil)
+	assert.Contains(t, rec.Body.String(), `go_sql_max_open_connections{db_name="sqlite"} 1`)
+}
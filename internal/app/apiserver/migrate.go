@@ -0,0 +1,96 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"rest_api/internal/app/migrate"
+	"strconv"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up|down|status|goto VERSION")
+
+// Migrate runs the migrate command against the configured database. args
+// are up, down, status or goto followed by a version; down rolls back one
+// migration and goto 0 all of them.
+func Migrate(config *Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	db, fsys, err := openDB(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, fsys)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		err = m.Up()
+	case args[0] == "down" && len(args) == 1:
+		err = m.Down()
+	case args[0] == "goto" && len(args) == 2:
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil {
+			return errMigrateUsage
+		}
+
+		err = m.Goto(version)
+	case args[0] == "status" && len(args) == 1:
+		return printMigrationStatus(m, out)
+	default:
+		return errMigrateUsage
+	}
+
+	if err == migrate.ErrNoChange {
+		fmt.Fprintln(out, "no change")
+		err = nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	version, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "database is at version %d\n", version)
+
+	return nil
+}
+
+func printMigrationStatus(m *migrate.Migrator, out io.Writer) error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	ss, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "version %d, latest %d", version, m.Latest())
+	if dirty {
+		fmt.Fprint(out, ", dirty")
+	}
+	fmt.Fprintln(out)
+
+	for _, s := range ss {
+		state := "pending"
+		if s.Applied {
+			state = "applied"
+		}
+
+		fmt.Fprintf(out, "%-8s %d %s\n", state, s.Version, s.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"rest_api/internal/app/model"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidPageLimit = fmt.Errorf("limit must be between 1 and %d", model.MaxPageSize)
+)
+
+type articlePage struct {
+	Articles []*model.Article `json:"articles"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// articleFilter reads the listing parameters shared by all article
+// collections: author_id, notebook_id, from and to (YYYY-MM-DD, inclusive),
+// status, tag (repeatable) with tag_mode=all|any, sort, cursor and limit.
+func articleFilter(r *http.Request) (*model.ArticleFilter, error) {
+	q := r.URL.Query()
+
+	f := &model.ArticleFilter{
+		Sort: q.Get("sort"),
+		Limit: model.DefaultPageSize,
+	}
+
+	if _, _, err := f.SortKey(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if v := q.Get("author_id"); v != "" {
+		if f.AuthorID, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("notebook_id"); v != "" {
+		if f.NotebookID, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse(dateLayout, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse(dateLayout, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Status = q.Get("status"); f.Status != "" && !model.ValidStatus(f.Status) {
+		return nil, model.ErrInvalidStatus
+	}
+
+	if len(q["tag"]) > 0 {
+		if f.Tags, err = model.NormalizeTags(q["tag"]); err != nil {
+			return nil, err
+		}
+	}
+
+	switch f.TagMode = q.Get("tag_mode"); f.TagMode {
+	case "", model.TagModeAll, model.TagModeAny:
+	default:
+		return nil, model.ErrInvalidTagMode
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > model.MaxPageSize {
+			return nil, errInvalidPageLimit
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		if f.Cursor, err = model.DecodeArticleCursor(v, f.Sort); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// respondPage writes a page of articles. The next page is announced both in
+// the body and in a Link header that repeats the request with the new cursor.
+func (s *server) respondPage(w http.ResponseWriter, r *http.Request, ars []*model.Article, next *model.ArticleCursor) {
+	page := &articlePage{Articles: ars}
+
+	if next != nil {
+		page.NextCursor = next.Encode()
+
+		q := r.URL.Query()
+		q.Set("cursor", page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, q.Encode()))
+	}
+
+	etag := articlesETag(ars)
+	if s.notModified(w, r, etag) {
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	s.respond(w, r, http.StatusOK, page)
+}
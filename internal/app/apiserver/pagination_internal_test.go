@@ -0,0 +1,102 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+func TestServer_HandleShowAllArticlesPagination(t *testing.T) {
+	ts := teststore.New()
+	testAuthors(t, ts, 2)
+	for i, heading := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+		ts.Article().CreateArticle(context.Background(), &model.Article{Heading: heading, Text: "text", AuthorID: i % 2 + 1, Status: model.StatusPublished})
+	}
+
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+
+	pages := func(query string) ([][]string, int) {
+		result := make([][]string, 0)
+		next := "/articles" + query
+
+		for next != "" {
+			rec := testRequest(s, http.MethodGet, next, "", nil)
+			if rec.Code != http.StatusOK {
+				return nil, rec.Code
+			}
+
+			page := &articlePage{}
+			json.NewDecoder(rec.Body).Decode(page)
+
+			headings := make([]string, 0)
+			for _, a := range page.Articles {
+				headings = append(headings, a.Heading)
+			}
+			result = append(result, headings)
+
+			next = ""
+			if page.NextCursor != "" {
+				expected := fmt.Sprintf(`cursor=%s`, url.QueryEscape(page.NextCursor))
+				assert.Contains(t, rec.Header().Get("Link"), expected)
+				assert.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+
+				link := rec.Header().Get("Link")
+				next = link[1:len(link) - len(`>; rel="next"`)]
+			} else {
+				assert.Empty(t, rec.Header().Get("Link"))
+			}
+		}
+
+		return result, http.StatusOK
+	}
+
+	testCases := []struct {
+		name string
+		query string
+		expectedCode int
+		expectedPages [][]string
+	}{
+		{"newest first", "?limit=2", http.StatusOK, [][]string{{"bravo", "charlie"}, {"echo", "alpha"}, {"delta"}}},
+		{"heading", "?sort=heading&limit=2", http.StatusOK, [][]string{{"alpha", "bravo"}, {"charlie", "delta"}, {"echo"}}},
+		{"heading desc", "?sort=-heading&limit=3", http.StatusOK, [][]string{{"echo", "delta", "charlie"}, {"bravo", "alpha"}}},
+		{"author", "?author_id=1&sort=heading", http.StatusOK, [][]string{{"bravo", "delta", "echo"}}},
+		{"exact page", "?sort=heading&limit=5", http.StatusOK, [][]string{{"alpha", "bravo", "charlie", "delta", "echo"}}},
+		{"invalid sort", "?sort=text", http.StatusBadRequest, nil},
+		{"invalid limit", "?limit=0", http.StatusBadRequest, nil},
+		{"invalid cursor", "?cursor=nope", http.StatusBadRequest, nil},
+		{"invalid cursor date", "?cursor=" + (&model.ArticleCursor{Sort: model.DefaultArticleSort, Key: "yesterday", ID: 1}).Encode(), http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, code := pages(tc.query)
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedPages, result)
+		})
+	}
+}
+
+func TestServer_HandleShowAllArticlesCursorSort(t *testing.T) {
+	ts := teststore.New()
+	testAuthors(t, ts, 1)
+	ts.Article().CreateArticle(context.Background(), &model.Article{Heading: "one", Text: "text", AuthorID: 1, Status: model.StatusPublished})
+	ts.Article().CreateArticle(context.Background(), &model.Article{Heading: "two", Text: "text", AuthorID: 1, Status: model.StatusPublished})
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+
+	rec := testRequest(s, http.MethodGet, "/show_all_articles?limit=1", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	page := &articlePage{}
+	json.NewDecoder(rec.Body).Decode(page)
+	assert.NotEmpty(t, page.NextCursor)
+
+	rec = testRequest(s, http.MethodGet, "/show_all_articles?sort=heading&cursor="+page.NextCursor, "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,114 @@
+package apiserver
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"math"
+	"net"
+	"net/http"
+	"rest_api/internal/app/logging"
+	"rest_api/internal/app/ratelimit"
+	"strconv"
+	"time"
+)
+
+// The route groups that config.RateLimits has policies for.
+const (
+	rateLimitAuthorize = "authorize"
+	rateLimitCreate = "create"
+	rateLimitPublic = "public"
+	rateLimitPrivate = "private"
+	rateLimitToken = "token"
+)
+
+const (
+	rateLimitByIP = "ip"
+	rateLimitByUser = "user"
+)
+
+// rateLimit counts the requests of a group of routes against the group's
+// policy in config.RateLimits and refuses them with 429 once the bucket of
+// the client is empty. Every answer carries the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers; refused ones also carry
+// Retry-After. Groups without a policy are not limited.
+//
+// It runs ahead of authentication. For policies keyed by user it parses the
+// token itself and leaves the result on the context for authentication to
+// use; requests without a valid token are counted by address.
+func (s *server) rateLimit(group string) mux.MiddlewareFunc {
+	limit := s.rateLimits[group]
+	p := ratelimit.Policy{
+		Rate: limit.Rate,
+		Period: limit.Period.Duration,
+		Burst: limit.Burst,
+	}
+
+	if !p.Enabled() {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			kr := r
+			if tokenHeader := r.Header.Get("Authorization"); limit.Key == rateLimitByUser && tokenHeader != "" && principalFromContext(r.Context()) == nil {
+				p, err := s.parseToken(r, tokenHeader)
+				r = r.WithContext(context.WithValue(r.Context(), ctxKeyToken, &parsedToken{p, err}))
+
+				kr = r
+				if err == nil {
+					kr = r.WithContext(context.WithValue(r.Context(), ctxKeyUser, p))
+				}
+			}
+
+			key := group + ":" + rateLimitKey(kr, limit.Key)
+
+			res, err := s.limiter.Allow(r.Context(), key, p)
+			if err != nil {
+				// A backend that is down should not take the API down
+				// with it, so the request is let through.
+				logging.FromContext(r.Context()).Error("rate limiting", "error", err, "group", group)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", seconds(res.Reset))
+
+			if !res.Allowed {
+				h.Set("Retry-After", seconds(res.RetryAfter))
+				s.error(w, r, errRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey names the bucket a request is counted in: the authenticated
+// user for policies keyed by user, otherwise the client's address. Requests
+// without a user are counted by address either way.
+func rateLimitKey(r *http.Request, by string) string {
+	if by == rateLimitByUser {
+		if p := principalFromContext(r.Context()); p != nil {
+			return "user:" + strconv.Itoa(p.UserID)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// seconds rounds d up to whole seconds, so that a client waiting that long
+// does not come back too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,201 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/ratelimit"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+func testRateLimitRequest(s *server, method string, url string, remoteAddr string, token string, payload interface{}) *httptest.ResponseRecorder {
+	b := &bytes.Buffer{}
+	if payload != nil {
+		json.NewEncoder(b).Encode(payload)
+	}
+
+	req, _ := http.NewRequest(method, url, b)
+	req.RemoteAddr = remoteAddr
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer " + token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestServer_RateLimitByIP(t *testing.T) {
+	config := NewConfig()
+	config.RateLimits[rateLimitAuthorize] = RateLimit{Rate: 2, Period: Duration{time.Minute}, Burst: 2, Key: rateLimitByIP}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	payload := map[string]string{
+		"email": "user@mail.com",
+		"password": "wrong",
+	}
+
+	rec := testRateLimitRequest(s, http.MethodPost, "/authorize", "192.0.2.1:1234", "", payload)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rec.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+
+	// The port differs from one connection to the next.
+	rec = testRateLimitRequest(s, http.MethodPost, "/authorize", "192.0.2.1:4321", "", payload)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = testRateLimitRequest(s, http.MethodPost, "/authorize", "192.0.2.1:1234", "", payload)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	p := &problem{}
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, "rate_limited", p.Code)
+
+	rec = testRateLimitRequest(s, http.MethodPost, "/authorize", "198.51.100.7:1234", "", payload)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Other groups have buckets of their own.
+	rec = testRateLimitRequest(s, http.MethodGet, "/articles", "192.0.2.1:1234", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "100", rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestServer_RateLimitByUser(t *testing.T) {
+	config := NewConfig()
+	config.RateLimits[rateLimitPrivate] = RateLimit{Rate: 1, Period: Duration{time.Minute}, Burst: 1, Key: rateLimitByUser}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	_, token1 := testUserToken(t, s, "user1@mail.com")
+	_, token2 := testUserToken(t, s, "user2@mail.com")
+
+	rec := testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", token1, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", token1, nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	rec = testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", token2, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Requests without a valid token are counted by address.
+	rec = testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestServer_RateLimitUnauthenticated(t *testing.T) {
+	config := NewConfig()
+	config.RateLimits[rateLimitPrivate] = RateLimit{Rate: 2, Period: Duration{time.Minute}, Burst: 2, Key: rateLimitByUser}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	_, token := testUserToken(t, s, "user@mail.com")
+
+	rec := testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", token + "forged", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", "not.a.token", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	// A valid token is counted for its user, not for the address.
+	rec = testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "192.0.2.1:1234", token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = testRateLimitRequest(s, http.MethodGet, "/private/notebooks", "198.51.100.7:1234", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_RateLimitTokenRoutes(t *testing.T) {
+	config := NewConfig()
+	config.RateLimits[rateLimitToken] = RateLimit{Rate: 2, Period: Duration{time.Minute}, Burst: 2, Key: rateLimitByIP}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	payload := map[string]string{
+		"refresh_token": "unknown",
+	}
+
+	rec := testRateLimitRequest(s, http.MethodPost, "/token/refresh", "192.0.2.1:1234", "", payload)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = testRateLimitRequest(s, http.MethodPost, "/logout", "192.0.2.1:1234", "", payload)
+	assert.NotEqual(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = testRateLimitRequest(s, http.MethodPost, "/token/refresh", "192.0.2.1:1234", "", payload)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	rec = testRateLimitRequest(s, http.MethodPost, "/logout", "192.0.2.1:1234", "", payload)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	// Logging in is counted in a bucket of its own.
+	rec = testRateLimitRequest(s, http.MethodPost, "/authorize", "192.0.2.1:1234", "", map[string]string{
+		"email": "user@mail.com",
+		"password": "wrong",
+	})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestServer_RateLimitDisabled(t *testing.T) {
+	config := NewConfig()
+	config.RateLimits[rateLimitCreate] = RateLimit{}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	rec := testRateLimitRequest(s, http.MethodPost, "/create", "192.0.2.1:1234", "", map[string]string{
+		"name": "user",
+		"email": "user@mail.com",
+		"password": "123456",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+
+	rec = testRateLimitRequest(s, http.MethodGet, "/healthz", "192.0.2.1:1234", "", nil)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, p ratelimit.Policy) (*ratelimit.Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestServer_RateLimitBackendDown(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	s.limiter = failingLimiter{}
+
+	rec := testRateLimitRequest(s, http.MethodGet, "/articles", "192.0.2.1:1234", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitKey(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/private/notebooks", nil)
+	req.RemoteAddr = "[2001:db8::1]:443"
+	assert.Equal(t, "ip:2001:db8::1", rateLimitKey(req, rateLimitByIP))
+	assert.Equal(t, "ip:2001:db8::1", rateLimitKey(req, rateLimitByUser))
+
+	req = req.WithContext(context.WithValue(req.Context(), ctxKeyUser, &auth.Principal{UserID: 7}))
+	assert.Equal(t, "user:7", rateLimitKey(req, rateLimitByUser))
+	assert.Equal(t, "ip:2001:db8::1", rateLimitKey(req, rateLimitByIP))
+}
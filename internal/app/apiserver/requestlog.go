@@ -0,0 +1,112 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/logging"
+	"time"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// requestLog collects what the access log line needs but only becomes known
+// further down the chain, such as the authenticated user.
+type requestLog struct {
+	userID int
+}
+
+// logRequests gives every request an ID, taken from the X-Request-ID header
+// when the client or a proxy sent a usable one, and a logger that carries
+// it along with the ID of the request's trace. Once the request is answered
+// it is logged with its route template, status, duration and size. It wraps
+// the router, so that requests no route matches are logged as well.
+func (s *server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		logger := s.logger.With("request_id", id)
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			logger = logger.With("trace_id", sc.TraceID().String())
+		}
+		entry := &requestLog{}
+
+		ctx := logging.NewContext(r.Context(), logger)
+		ctx = context.WithValue(ctx, ctxKeyRequestLog, entry)
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		route := routeTemplate(r)
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rw.status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds()) / 1000),
+			slog.Int("bytes", rw.size),
+		}
+
+		if entry.userID != 0 {
+			attrs = append(attrs, slog.Int("user_id", entry.userID))
+		}
+
+		level := slog.LevelInfo
+		if rw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logger.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// withPrincipal returns r with p as its authenticated user. The request's
+// logger and its access log line get the user ID as well.
+func withPrincipal(r *http.Request, p *auth.Principal) *http.Request {
+	ctx := context.WithValue(r.Context(), ctxKeyUser, p)
+	ctx = logging.NewContext(ctx, logging.FromContext(ctx).With("user_id", p.UserID))
+
+	if entry, ok := ctx.Value(ctxKeyRequestLog).(*requestLog); ok {
+		entry.userID = p.UserID
+	}
+
+	return r.WithContext(ctx)
+}
+
+// validRequestID accepts IDs of visible ASCII characters only, so that a
+// client cannot break up log lines with the ID it sends.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,136 @@
+package apiserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/logging"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+func TestServer_LogRequests(t *testing.T) {
+	out := &bytes.Buffer{}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	s.logger = logging.New(out, slog.LevelInfo, logging.FormatJSON)
+
+	u, token := testUserToken(t, s, "user@mail.com")
+
+	out.Reset()
+
+	req, _ := http.NewRequest(http.MethodGet, "/articles/100", nil)
+	req.Header.Set(requestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, "abc-123", rec.Header().Get(requestIDHeader))
+
+	req, _ = http.NewRequest(http.MethodGet, "/private/notebooks", nil)
+	req.Header.Set(requestIDHeader, "not a valid id")
+	req.Header.Set("Authorization", "Bearer " + token)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Len(t, rec.Header().Get(requestIDHeader), 32)
+
+	lines := testLogLines(t, out)
+	assert.Len(t, lines, 2)
+
+	assert.Equal(t, "request", lines[0]["msg"])
+	assert.Equal(t, "INFO", lines[0]["level"])
+	assert.Equal(t, "abc-123", lines[0]["request_id"])
+	assert.Equal(t, "GET", lines[0]["method"])
+	assert.Equal(t, "/articles/{id:[0-9]+}", lines[0]["route"])
+	assert.Equal(t, "/articles/100", lines[0]["path"])
+	assert.Equal(t, 404.0, lines[0]["status"])
+	assert.Greater(t, lines[0]["bytes"], 0.0)
+	assert.Contains(t, lines[0], "duration_ms")
+	assert.NotContains(t, lines[0], "user_id")
+
+	assert.Equal(t, rec.Header().Get(requestIDHeader), lines[1]["request_id"])
+	assert.Equal(t, "/private/notebooks", lines[1]["route"])
+	assert.Equal(t, float64(u.ID), lines[1]["user_id"])
+}
+
+func TestServer_LogUnmatchedRequests(t *testing.T) {
+	out := &bytes.Buffer{}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	s.logger = logging.New(out, slog.LevelInfo, logging.FormatJSON)
+
+	req, _ := http.NewRequest(http.MethodGet, "/no/such/path", nil)
+	req.Header.Set(requestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "abc-123", rec.Header().Get(requestIDHeader))
+
+	req, _ = http.NewRequest(http.MethodDelete, "/hello", nil)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Len(t, rec.Header().Get(requestIDHeader), 32)
+
+	lines := testLogLines(t, out)
+	assert.Len(t, lines, 2)
+
+	assert.Equal(t, "abc-123", lines[0]["request_id"])
+	assert.Equal(t, routeUnmatched, lines[0]["route"])
+	assert.Equal(t, "/no/such/path", lines[0]["path"])
+	assert.Equal(t, 404.0, lines[0]["status"])
+
+	assert.Equal(t, rec.Header().Get(requestIDHeader), lines[1]["request_id"])
+	assert.Equal(t, "DELETE", lines[1]["method"])
+	assert.Equal(t, 405.0, lines[1]["status"])
+}
+
+func TestServer_RequestLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	s.logger = logging.New(out, slog.LevelInfo, logging.FormatJSON)
+
+	s.router.HandleFunc("/log", func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("from handler")
+		s.error(w, r, assert.AnError)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/log", nil)
+	req.Header.Set(requestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	lines := testLogLines(t, out)
+	assert.Len(t, lines, 3)
+
+	assert.Equal(t, "from handler", lines[0]["msg"])
+	assert.Equal(t, "abc-123", lines[0]["request_id"])
+
+	assert.Equal(t, "internal error", lines[1]["msg"])
+	assert.Equal(t, "abc-123", lines[1]["request_id"])
+	assert.Equal(t, assert.AnError.Error(), lines[1]["error"])
+
+	assert.Equal(t, "request", lines[2]["msg"])
+	assert.Equal(t, "ERROR", lines[2]["level"])
+	assert.NotContains(t, rec.Body.String(), assert.AnError.Error())
+}
+
+func testLogLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	lines := make([]map[string]interface{}, 0)
+
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		line := make(map[string]interface{})
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -7,45 +7,165 @@ import (
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
 	"net/http"
-	"os"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/logging"
+	"rest_api/internal/app/migrate"
 	"rest_api/internal/app/model"
+	"rest_api/internal/app/ratelimit"
+	"rest_api/internal/app/search"
 	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/metricstore"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errIncorrectEmailOrPassword = unauthorized("invalid_credentials", "invalid email or password")
+	errMissingToken = unauthorized("missing_token", "missing auth token")
+	errMalformedToken = &requestError{http.StatusBadRequest, "malformed_token", errors.New("invalid or malformed auth token")}
+	errInvalidToken = unauthorized("invalid_token", "token is not valid")
+	errTokenExpired = unauthorized("token_expired", "token is expired")
+	errInvalidRefreshToken = unauthorized("invalid_refresh_token", "invalid refresh token")
+	errRefreshTokenExpired = unauthorized("refresh_token_expired", "refresh token is expired")
+	errRefreshTokenReused = unauthorized("refresh_token_reused", "refresh token has already been used")
+	errForbidden = forbidden("you are not allowed to perform this action")
+	errDatabaseTimeout = &requestError{http.StatusGatewayTimeout, "database_timeout", errors.New("the database did not answer in time")}
+	errInvalidLimit = badRequest(fmt.Errorf("limit must be between 1 and %d", maxSearchLimit))
+	errBodyTooLarge = &requestError{http.StatusRequestEntityTooLarge, "body_too_large", errors.New("request body is too large")}
+	errTrailingData = badRequest(errors.New("request body must contain a single JSON value"))
+	errRateLimited = &requestError{http.StatusTooManyRequests, "rate_limited", errors.New("too many requests, try again later")}
+)
+
+const (
+	dateLayout = "2006-01-02"
+	defaultSearchLimit = 20
+	maxSearchLimit = 100
+)
+
+type ctxKey int8
+
+const (
+	ctxKeyUser ctxKey = iota
+	ctxKeyRequestLog
+	ctxKeyToken
+	ctxKeyRoute
 )
 
 type server struct {
 	router *mux.Router
+	handler http.Handler
+	metrics *metrics
+	tracer trace.Tracer
+	propagator propagation.TextMapPropagator
+	logger *slog.Logger
 	store store.Store
+	keys *auth.KeyManager
+	accessTokenTTL time.Duration
+	refreshTokenTTL time.Duration
+	databaseTimeout time.Duration
+	maxBodySize int64
+	healthCheckTimeout time.Duration
+	limiter ratelimit.Limiter
+	rateLimits map[string]RateLimit
+	workers []*worker
+	migrator *migrate.Migrator
+	ready int32
 }
 
-func newServer(store store.Store) *server {
+func newServer(store store.Store, keys *auth.KeyManager, config *Config) *server {
+	m := newMetrics()
+
 	srv := &server {
 		router: mux.NewRouter(),
-		store: store,
+		metrics: m,
+		tracer: otel.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+		logger: slog.Default(),
+		store: metricstore.New(store, metricstore.NewMetrics(m.registry)),
+		keys: keys,
+		accessTokenTTL: config.AccessTokenTTL.Duration,
+		refreshTokenTTL: config.RefreshTokenTTL.Duration,
+		databaseTimeout: config.DatabaseTimeout.Duration,
+		maxBodySize: config.MaxBodySize,
+		healthCheckTimeout: config.HealthCheckTimeout.Duration,
+		limiter: ratelimit.NewMemory(),
+		rateLimits: config.RateLimits,
+	}
+
+	srv.workers = []*worker{
+		newPublisher(srv.store, config.PublishInterval.Duration),
+		newPurger(srv.store, config.PurgeInterval.Duration, config.TrashRetention.Duration),
 	}
 
 	srv.configureRouter()
+	srv.handler = srv.matchRoute(srv.instrument(srv.traceRequests(srv.logRequests(srv.router))))
 
 	return srv
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.router.ServeHTTP(w, r)
+	s.handler.ServeHTTP(w, r)
 }
 
 func (s *server) configureRouter() {
+	s.router.Use(s.databaseDeadline)
 	s.router.HandleFunc("/hello", s.hello()).Methods("GET")
-	s.router.HandleFunc("/create", s.handleCreateUser()).Methods("POST")
-	s.router.HandleFunc("/find/article", s.handleFindArticleByHeading()).Methods("GET")
-	s.router.HandleFunc("/show_all_articles", s.handleShowAllArticles()).Methods("GET")
-	s.router.HandleFunc("/authorize", s.handleAuthorizeUser()).Methods("POST")
-
+	s.router.HandleFunc("/healthz", s.handleHealthz()).Methods("GET")
+	s.router.HandleFunc("/readyz", s.handleReadyz()).Methods("GET")
+	s.router.Handle("/metrics", s.metrics.handler()).Methods("GET")
+	s.router.HandleFunc("/.well-known/jwks.json", s.handleJWKS()).Methods("GET")
+	s.router.Handle("/create", s.rateLimit(rateLimitCreate)(s.handleCreateUser())).Methods("POST")
+	s.router.Handle("/authorize", s.rateLimit(rateLimitAuthorize)(s.handleAuthorizeUser())).Methods("POST")
+	s.router.Handle("/token/refresh", s.rateLimit(rateLimitToken)(s.handleRefreshToken())).Methods("POST")
+	s.router.Handle("/logout", s.rateLimit(rateLimitToken)(s.handleLogout())).Methods("POST")
+
+	// Article reads are public, but a token lets owners see their own
+	// drafts and private articles as well.
+	public := s.router.NewRoute().Subrouter()
+	public.Use(s.rateLimit(rateLimitPublic))
+	public.Use(s.OptionalAuthentication)
+	public.HandleFunc("/find/article", s.handleFindArticleByHeading()).Methods("GET")
+	public.HandleFunc("/show_all_articles", s.handleShowAllArticles()).Methods("GET")
+	public.HandleFunc("/articles", s.handleShowAllArticles()).Methods("GET")
+	public.HandleFunc("/articles/search", s.handleSearchArticles()).Methods("GET")
+	public.HandleFunc("/articles/{id:[0-9]+}", s.handleFindArticle()).Methods("GET")
+
+	// Limits are checked ahead of authentication, so that requests with a
+	// missing, expired or forged token are counted as well.
 	private := s.router.PathPrefix("/private").Subrouter()
+	private.Use(s.rateLimit(rateLimitPrivate))
 	private.Use(s.JwtAuthentication)
 	private.HandleFunc("/create/article", s.handleCreateArticle()).Methods("POST")
 	private.HandleFunc("/delete/article", s.handleDeleteArticle()).Methods("DELETE")
 	private.HandleFunc("/change/article", s.handleChangeArticle()).Methods("PUT")
+	private.HandleFunc("/move/article", s.handleMoveArticle()).Methods("PUT")
+	private.HandleFunc("/articles/{id:[0-9]+}/revisions", s.handleShowRevisions()).Methods("GET")
+	private.HandleFunc("/articles/{id:[0-9]+}/revisions/{revision:[0-9]+}", s.handleFindRevision()).Methods("GET")
+	private.HandleFunc("/articles/{id:[0-9]+}/revisions/{revision:[0-9]+}/restore", s.handleRestoreRevision()).Methods("POST")
+	private.HandleFunc("/articles/{id:[0-9]+}/diff", s.handleDiffRevisions()).Methods("GET")
+	private.HandleFunc("/notebooks", s.handleCreateNotebook()).Methods("POST")
+	private.HandleFunc("/notebooks", s.handleShowNotebooks()).Methods("GET")
+	private.HandleFunc("/notebooks/{id:[0-9]+}", s.handleRenameNotebook()).Methods("PUT")
+	private.HandleFunc("/notebooks/{id:[0-9]+}", s.handleDeleteNotebook()).Methods("DELETE")
+	private.HandleFunc("/notebooks/{id:[0-9]+}/articles", s.handleShowNotebookArticles()).Methods("GET")
+	private.HandleFunc("/articles/{id:[0-9]+}/publish", s.handlePublishArticle()).Methods("POST")
+	private.HandleFunc("/articles/{id:[0-9]+}/unpublish", s.handleUnpublishArticle()).Methods("POST")
+	private.HandleFunc("/articles/{id:[0-9]+}/archive", s.handleArchiveArticle()).Methods("POST")
+	private.HandleFunc("/articles/{id:[0-9]+}/visibility", s.handleChangeVisibility()).Methods("PUT")
+	private.HandleFunc("/trash", s.handleShowTrash()).Methods("GET")
+	private.HandleFunc("/trash", s.handleEmptyTrash()).Methods("DELETE")
+	private.HandleFunc("/trash/{id:[0-9]+}/restore", s.handleRestoreArticle()).Methods("POST")
+	private.HandleFunc("/tags", s.handleShowTags()).Methods("GET")
+	private.HandleFunc("/tags/{id:[0-9]+}", s.handleRenameTag()).Methods("PUT")
+	private.HandleFunc("/tags/{id:[0-9]+}/merge", s.handleMergeTag()).Methods("POST")
 }
 
 func (s *server) handleCreateUser() http.HandlerFunc {
@@ -58,13 +178,14 @@ func (s *server) handleCreateUser() http.HandlerFunc {
 	type response struct {
 		User *model.User `json:"user"`
 		Token string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := &request{}
 
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-			s.error(w, r, http.StatusBadRequest, err)
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
 			return
 		}
 
@@ -74,20 +195,23 @@ func (s *server) handleCreateUser() http.HandlerFunc {
 			Password: req.Password,
 		}
 
-		if err := s.store.User().Create(u); err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+		if err := s.store.User().Create(r.Context(), u); err != nil {
+			s.error(w, r, err)
 			return
 		}
 
 		u.Sanitize()
 
-		tk := &model.Token{ID: u.ID}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, tk)
-		tokenString, _ := token.SignedString([]byte(os.Getenv("token_password")))
+		tokenString, refreshToken, err := s.issueTokens(r.Context(), u, "")
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
 
 		resp := response{
 			User: u,
 			Token: tokenString,
+			RefreshToken: refreshToken,
 		}
 
 		s.respond(w, r, http.StatusCreated, resp)
@@ -100,59 +224,284 @@ func (s *server) handleAuthorizeUser() http.HandlerFunc {
 		Password string `json:"password"`
 	}
 
+	type response struct {
+		Token string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		// An unknown email is answered like a wrong password, so that the
+		// endpoint does not tell which accounts exist.
+		u, err := s.store.User().FindByEmail(r.Context(), req.Email)
+		if err == store.ErrRecordNotFound {
+			s.error(w, r, errIncorrectEmailOrPassword)
+			return
+		}
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		_, span := s.tracer.Start(r.Context(), "bcrypt.Compare")
+		err = u.ComparePassword(req.Password)
+		span.End()
+
+		if err != nil {
+			s.error(w, r, errIncorrectEmailOrPassword)
+			return
+		}
+
+		tokenString, refreshToken, err := s.issueTokens(r.Context(), u, "")
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, response{
+			Token: tokenString,
+			RefreshToken: refreshToken,
+		})
+	}
+}
+
+func (s *server) handleRefreshToken() http.HandlerFunc {
+	type request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	type response struct {
+		Token string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		rt, err := s.store.RefreshToken().FindByHash(r.Context(), model.HashRefreshToken(req.RefreshToken))
+		if err == store.ErrRecordNotFound {
+			s.error(w, r, errInvalidRefreshToken)
+			return
+		}
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		if rt.IsRevoked() {
+			s.revokeReusedFamily(w, r, rt.FamilyID)
+			return
+		}
+
+		if rt.IsExpired() {
+			s.error(w, r, errRefreshTokenExpired)
+			return
+		}
+
+		// A failed revoke means another request rotated this token first, so
+		// it is treated the same way as presenting an already rotated token.
+		if err := s.store.RefreshToken().Revoke(r.Context(), rt.ID); err != nil {
+			if err == store.ErrRecordNotFound {
+				s.revokeReusedFamily(w, r, rt.FamilyID)
+				return
+			}
+
+			s.error(w, r, err)
+			return
+		}
+
+		u, err := s.store.User().Find(r.Context(), rt.UserID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		tokenString, refreshToken, err := s.issueTokens(r.Context(), u, rt.FamilyID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, response{
+			Token: tokenString,
+			RefreshToken: refreshToken,
+		})
+	}
+}
+
+func (s *server) handleLogout() http.HandlerFunc {
+	type request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := &request{}
 
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-			s.error(w, r, http.StatusBadRequest, err)
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
 			return
 		}
 
-		u, err := s.store.User().FindByEmail(req.Email)
+		rt, err := s.store.RefreshToken().FindByHash(r.Context(), model.HashRefreshToken(req.RefreshToken))
+		if err == store.ErrRecordNotFound {
+			s.error(w, r, errInvalidRefreshToken)
+			return
+		}
 		if err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+			s.error(w, r, err)
 			return
 		}
 
-		if err := u.ComparePassword(req.Password); err != nil {
-			s.error(w, r, http.StatusForbidden, errors.New("invalid email or password"))
+		if err := s.store.RefreshToken().RevokeFamily(r.Context(), rt.FamilyID); err != nil {
+			s.error(w, r, err)
 			return
 		}
 
-		tk := &model.Token{ID: u.ID}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, tk)
-		tokenString, _ := token.SignedString([]byte(os.Getenv("token_string")))
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+func (s *server) revokeReusedFamily(w http.ResponseWriter, r *http.Request, familyID string) {
+	if err := s.store.RefreshToken().RevokeFamily(r.Context(), familyID); err != nil {
+		s.error(w, r, err)
+		return
+	}
+
+	s.error(w, r, errRefreshTokenReused)
+}
+
+// issueTokens signs a short-lived access token for the user and stores a new
+// refresh token in the given family. An empty familyID starts a new login
+// session.
+func (s *server) issueTokens(ctx context.Context, u *model.User, familyID string) (string, string, error) {
+	now := time.Now()
+	tk := &model.Token{
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt: now.Unix(),
+			ExpiresAt: now.Add(s.accessTokenTTL).Unix(),
+		},
+		ID: u.ID,
+		Role: u.Role,
+	}
+
+	tokenString, err := s.keys.Sign(tk)
+	if err != nil {
+		return "", "", err
+	}
 
-		s.respond(w, r, http.StatusOK, tokenString)
+	rt, refreshToken, err := model.NewRefreshToken(u.ID, familyID, s.refreshTokenTTL)
+	if err != nil {
+		return "", "", err
 	}
+
+	if err := s.store.RefreshToken().Create(ctx, rt); err != nil {
+		return "", "", err
+	}
+
+	return tokenString, refreshToken, nil
+}
+
+// articleRequestFields names the fields of model.Article the way article
+// requests do.
+var articleRequestFields = map[string]string{
+	"article_heading": "article_header",
 }
 
 func (s *server) handleCreateArticle() http.HandlerFunc {
 	type request struct {
 		ArticleHeader string `json:"article_header"`
 		ArticleText string `json:"article_text"`
-		AuthorID int `json:"author_id"`
+		NotebookID int `json:"notebook_id"`
+		Tags []string `json:"tags"`
+		Visibility string `json:"visibility"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := &request{}
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-			s.error(w, r, http.StatusBadRequest, err)
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		p := principalFromContext(r.Context())
+		if !p.Can(auth.ActionCreateArticle, nil) {
+			s.error(w, r, errForbidden)
+			return
+		}
+
+		if req.NotebookID != 0 {
+			n, err := s.store.Notebook().Find(r.Context(), req.NotebookID)
+			if err != nil {
+				s.error(w, r, err)
+				return
+			}
+
+			if n.OwnerID != p.UserID {
+				s.error(w, r, errForbidden)
+				return
+			}
+		}
+
+		tags, err := model.NormalizeTags(req.Tags)
+		if err != nil {
+			s.error(w, r, invalidField("tags", err))
 			return
 		}
 
 		a := &model.Article{
 			Heading: req.ArticleHeader,
 			Text: req.ArticleText,
-			AuthorID: req.AuthorID,
+			AuthorID: p.UserID,
+			NotebookID: req.NotebookID,
 			Date: "",
 		}
 
-		if err := s.store.Article().CreateArticle(a); err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+		if req.Visibility != "" {
+			if err := a.SetVisibility(req.Visibility); err != nil {
+				s.error(w, r, invalidField("visibility", err))
+				return
+			}
+		}
+
+		if err := a.Validate(); err != nil {
+			s.error(w, r, invalidRequest(err, articleRequestFields))
+			return
+		}
+
+		if err := s.store.WithTx(r.Context(), func(tx store.Store) error {
+			if err := tx.Article().CreateArticle(r.Context(), a); err != nil {
+				return err
+			}
+
+			if err := tx.Revision().Create(r.Context(), model.NewRevision(a, p.UserID)); err != nil {
+				return err
+			}
+
+			if len(tags) == 0 {
+				return nil
+			}
+
+			return tx.Tag().SetArticleTags(r.Context(), a.ID, a.AuthorID, tags)
+		}); err != nil {
+			s.error(w, r, err)
 			return
 		}
 
+		if len(tags) > 0 {
+			a.Tags = tags
+		}
+
 		s.respond(w, r, http.StatusCreated, a)
 	}
 }
@@ -165,35 +514,136 @@ func (s *server) handleFindArticleByHeading() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := &request{}
 
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-			s.error(w, r, http.StatusBadRequest, err)
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
 		}
 
-		ar, err := s.store.Article().FindByHeading(req.Header)
+		// Headings are not unique, so the store skips the articles the caller
+		// may not read rather than leaving a 404 for the first match.
+		ar, err := s.store.Article().FindByHeading(r.Context(), req.Header, principalFromContext(r.Context()).Audience())
 		if err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+			s.error(w, r, err)
+			return
+		}
+
+		etag := articleETag(ar)
+		if s.notModified(w, r, etag) {
+			return
 		}
 
+		w.Header().Set("ETag", etag)
 		s.respond(w, r, http.StatusOK, ar)
 	}
 }
 
-func (s *server) handleShowAllArticles() http.HandlerFunc {
-	type articles struct {
-		AricleList []*model.Article `json:"articles"`
+func (s *server) handleFindArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, badRequest(err))
+			return
+		}
+
+		ar, err := s.store.Article().Find(r.Context(), id)
+		if err == store.ErrRecordNotFound || (err == nil && !principalFromContext(r.Context()).CanRead(ar)) {
+			s.error(w, r, store.ErrRecordNotFound)
+			return
+		}
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		etag := articleETag(ar)
+		if s.notModified(w, r, etag) {
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		s.respond(w, r, http.StatusOK, ar)
+	}
+}
+
+// handleSearchArticles runs a full-text search. Besides q it accepts
+// author_id, from and to (YYYY-MM-DD, inclusive) and limit.
+func (s *server) handleSearchArticles() http.HandlerFunc {
+	type response struct {
+		Results []*model.SearchResult `json:"results"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		f := &model.SearchFilter{
+			Audience: principalFromContext(r.Context()).Audience(),
+			Query: q.Get("q"),
+			Limit: defaultSearchLimit,
+		}
+
+		var err error
+		if v := q.Get("author_id"); v != "" {
+			if f.AuthorID, err = strconv.Atoi(v); err != nil {
+				s.error(w, r, badRequest(err))
+				return
+			}
+		}
+
+		if v := q.Get("from"); v != "" {
+			if f.From, err = time.Parse(dateLayout, v); err != nil {
+				s.error(w, r, badRequest(err))
+				return
+			}
+		}
+
+		if v := q.Get("to"); v != "" {
+			if f.To, err = time.Parse(dateLayout, v); err != nil {
+				s.error(w, r, badRequest(err))
+				return
+			}
+		}
+
+		if v := q.Get("limit"); v != "" {
+			if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > maxSearchLimit {
+				s.error(w, r, errInvalidLimit)
+				return
+			}
+		}
+
+		results, err := s.store.Article().Search(r.Context(), f)
+		if err == search.ErrEmptyQuery {
+			s.error(w, r, badRequest(err))
+			return
+		}
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{Results: results})
 	}
+}
 
+// handleShowAllArticles lists articles one page at a time. See articleFilter
+// for the accepted parameters. Anonymous callers only get published public
+// articles, authenticated ones get their own articles in addition.
+func (s *server) handleShowAllArticles() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ars, err := s.store.Article().ShowAllArticles()
+		f, err := articleFilter(r)
 		if err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+			s.error(w, r, badRequest(err))
+			return
 		}
 
-		arts := &articles{
-			AricleList: ars,
+		f.Audience = principalFromContext(r.Context()).Audience()
+
+		ars, next, err := s.store.Article().List(r.Context(), f)
+		if err != nil {
+			s.error(w, r, err)
+			return
 		}
 
-		s.respond(w, r, http.StatusOK, arts)
+		s.respondPage(w, r, ars, next)
 	}
 }
 
@@ -202,13 +652,33 @@ func (s *server) handleChangeArticle() http.HandlerFunc {
 		ID int `json:"id"`
 		ArticleHeader string `json:"article_header"`
 		ArticleText string `json:"article_text"`
+		Version int `json:"version"`
+		Tags []string `json:"tags"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := &request{}
 
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-			s.error(w, r, http.StatusBadRequest, err)
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		a, ok := s.authorizeArticle(w, r, auth.ActionChangeArticle, req.ID)
+		if !ok {
+			return
+		}
+
+		version, ok := s.checkVersion(w, r, a, req.Version)
+		if !ok {
+			return
+		}
+
+		// Tags are only replaced when the request carries them, an empty
+		// list removes all of them.
+		tags, err := model.NormalizeTags(req.Tags)
+		if err != nil {
+			s.error(w, r, invalidField("tags", err))
 			return
 		}
 
@@ -216,19 +686,47 @@ func (s *server) handleChangeArticle() http.HandlerFunc {
 			ID: req.ID,
 			Heading: req.ArticleHeader,
 			Text: req.ArticleText,
+			Version: version,
 		}
 
-		if err := s.store.Article().ChangeArticleById(ar); err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+		if err := ar.Validate(); err != nil {
+			s.error(w, r, invalidRequest(err, articleRequestFields))
 			return
 		}
 
-		ar, err := s.store.Article().FindByHeading(ar.Heading)
-		if err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+		// The article is read back inside the transaction so that the
+		// response shows exactly what was written, tags included.
+		var changed *model.Article
+		if err := s.store.WithTx(r.Context(), func(tx store.Store) error {
+			if err := tx.Article().ChangeArticleById(r.Context(), ar); err != nil {
+				return err
+			}
+
+			if err := tx.Revision().Create(r.Context(), model.NewRevision(ar, principalFromContext(r.Context()).UserID)); err != nil {
+				return err
+			}
+
+			if req.Tags != nil {
+				if err := tx.Tag().SetArticleTags(r.Context(), ar.ID, a.AuthorID, tags); err != nil {
+					return err
+				}
+			}
+
+			changed, err = tx.Article().Find(r.Context(), ar.ID)
+			return err
+		}); err != nil {
+			if err == store.ErrEditConflict {
+				s.writeConflict(w, r, ar.ID)
+				return
+			}
+
+			s.error(w, r, err)
 			return
 		}
 
+		ar = changed
+
+		w.Header().Set("ETag", articleETag(ar))
 		s.respond(w, r, http.StatusOK, ar)
 	}
 }
@@ -236,6 +734,7 @@ func (s *server) handleChangeArticle() http.HandlerFunc {
 func (s *server) handleDeleteArticle() http.HandlerFunc {
 	type request struct {
 		ID int `json:"id"`
+		Version int `json:"version"`
 	}
 
 	type response struct {
@@ -245,25 +744,66 @@ func (s *server) handleDeleteArticle() http.HandlerFunc {
 	return func (w http.ResponseWriter, r *http.Request) {
 		req := &request{}
 
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-			s.error(w, r, http.StatusBadRequest, err)
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		a, ok := s.authorizeArticle(w, r, auth.ActionDeleteArticle, req.ID)
+		if !ok {
+			return
+		}
+
+		version, ok := s.checkVersion(w, r, a, req.Version)
+		if !ok {
 			return
 		}
 
-		h, err := s.store.Article().DeleteArticle(req.ID)
+		h, err := s.store.Article().DeleteArticle(r.Context(), req.ID, version)
 		if err != nil {
-			s.error(w, r, http.StatusUnprocessableEntity, err)
+			if err == store.ErrEditConflict {
+				s.writeConflict(w, r, req.ID)
+				return
+			}
+
+			s.error(w, r, err)
 			return
 		}
 
 		resp := &response{
-			Message: fmt.Sprintf("Deleted article: %s", h),
+			Message: fmt.Sprintf("Moved article to trash: %s", h),
 		}
 
 		s.respond(w, r, http.StatusOK, resp)
 	}
 }
 
+// authorizeArticle loads the article and checks that the authenticated user
+// may perform the action on it. It writes the error response itself and
+// reports whether the handler should continue.
+func (s *server) authorizeArticle(w http.ResponseWriter, r *http.Request, action auth.Action, id int) (*model.Article, bool) {
+	a, err := s.store.Article().Find(r.Context(), id)
+	if err != nil {
+		s.error(w, r, err)
+		return nil, false
+	}
+
+	if !principalFromContext(r.Context()).Can(action, a) {
+		s.error(w, r, errForbidden)
+		return nil, false
+	}
+
+	return a, true
+}
+
+func (s *server) handleJWKS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		s.respond(w, r, http.StatusOK, s.keys.JWKS())
+	}
+}
+
 func (s *server) hello() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -277,47 +817,179 @@ func (s *server) JwtAuthentication(next http.Handler) http.Handler {
 		tokenHeader := r.Header.Get("Authorization")
 
 		if tokenHeader == "" {
-			s.respond(w, r, http.StatusUnauthorized, map[string]interface{}{"error": "Missing auth token!"})
+			s.error(w, r, errMissingToken)
 			return
 		}
 
-		splitted := strings.Split(tokenHeader, " ")
-		if len(splitted) != 2 {
-			s.respond(w, r, http.StatusBadRequest, map[string]interface{}{"error": "Invalid/Malformed auth token!"})
+		p, ok := s.authenticate(w, r, tokenHeader)
+		if !ok {
 			return
 		}
 
-		tokenPart := splitted[1]
-
-		tk := &model.Token{}
+		next.ServeHTTP(w, withPrincipal(r, p))
+	})
+}
 
-		token, err := jwt.ParseWithClaims(tokenPart, tk, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("token_password")), nil
-		})
+// OptionalAuthentication is JwtAuthentication for public routes: requests
+// without a token pass through anonymously, but a token that is sent has to
+// be valid.
+func (s *server) OptionalAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenHeader := r.Header.Get("Authorization")
+		if tokenHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-		if err != nil {
-			s.error(w, r, http.StatusForbidden, err)
+		p, ok := s.authenticate(w, r, tokenHeader)
+		if !ok {
 			return
 		}
 
-		if !token.Valid {
-			s.respond(w, r, http.StatusUnauthorized, map[string]interface{}{"error": "Token is not valid!"})
+		next.ServeHTTP(w, withPrincipal(r, p))
+	})
+}
+
+// authenticate validates the bearer token from the Authorization header. It
+// writes the error response itself and reports whether the request may
+// proceed.
+func (s *server) authenticate(w http.ResponseWriter, r *http.Request, tokenHeader string) (*auth.Principal, bool) {
+	p, err := s.tokenPrincipal(r, tokenHeader)
+	if err != nil {
+		s.error(w, r, err)
+		return nil, false
+	}
+
+	return p, true
+}
+
+// parsedToken is what parseToken made of a request's bearer token. It is kept
+// on the request context, so that the token is parsed once per request.
+type parsedToken struct {
+	principal *auth.Principal
+	err error
+}
+
+// tokenPrincipal is parseToken for tokens that an earlier middleware may
+// already have parsed.
+func (s *server) tokenPrincipal(r *http.Request, tokenHeader string) (*auth.Principal, error) {
+	if t, ok := r.Context().Value(ctxKeyToken).(*parsedToken); ok {
+		return t.principal, t.err
+	}
+
+	return s.parseToken(r, tokenHeader)
+}
+
+// parseToken returns the user the bearer token in tokenHeader was issued
+// to, or the error to answer the request with.
+func (s *server) parseToken(r *http.Request, tokenHeader string) (*auth.Principal, error) {
+	splitted := strings.Split(tokenHeader, " ")
+	if len(splitted) != 2 {
+		return nil, errMalformedToken
+	}
+
+	tokenPart := splitted[1]
+
+	tk := &model.Token{}
+
+	_, span := s.tracer.Start(r.Context(), "jwt.Parse")
+	token, err := jwt.ParseWithClaims(tokenPart, tk, s.keys.Keyfunc)
+	span.End()
+
+	if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+		return nil, errTokenExpired
+	}
+
+	if err != nil || !token.Valid {
+		return nil, errInvalidToken
+	}
+
+	return &auth.Principal{
+		UserID: tk.ID,
+		Role: tk.Role,
+	}, nil
+}
+
+// databaseDeadline bounds the request context by the configured database
+// timeout. Handlers pass that context to the store, so all queries made for
+// one request share the deadline.
+func (s *server) databaseDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.databaseTimeout <= 0 {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "user", tk.ID)
-		r = r.WithContext(ctx)
-		next.ServeHTTP(w, r)
+		ctx, cancel := context.WithTimeout(r.Context(), s.databaseTimeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func (s *server) error(w http.ResponseWriter, r *http.Request, code int, err error) {
-	s.respond(w, r, code, map[string]string{"error":err.Error()})
+// setReady marks whether the server should be sent new requests, which the
+// readiness probe reports. It is set once the server listens and cleared as
+// soon as it starts shutting down.
+func (s *server) setReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+
+	atomic.StoreInt32(&s.ready, v)
+}
+
+func (s *server) isReady() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+func principalFromContext(ctx context.Context) *auth.Principal {
+	p, _ := ctx.Value(ctxKeyUser).(*auth.Principal)
+	return p
 }
 
+
 func (s *server) respond(w http.ResponseWriter, r *http.Request, code int, data interface{}) {
 	w.WriteHeader(code)
 	if data != nil {
-		json.NewEncoder(w).Encode(data)
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			logging.FromContext(r.Context()).Error("encoding response", "error", err)
+		}
+	}
+}
+
+// decode reads the JSON body of the request into v. Bodies over the configured
+// size, fields that v does not have and anything after the first JSON value
+// are refused.
+func (s *server) decode(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	body := r.Body
+	if s.maxBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, s.maxBodySize)
+	}
+
+	d := json.NewDecoder(body)
+	d.DisallowUnknownFields()
+
+	if err := d.Decode(v); err != nil {
+		return decodeError(err)
+	}
+
+	if err := d.Decode(&json.RawMessage{}); err != io.EOF {
+		if err == nil {
+			return errTrailingData
+		}
+
+		return decodeError(err)
+	}
+
+	return nil
+}
+
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errBodyTooLarge
 	}
+
+	return badRequest(err)
 }
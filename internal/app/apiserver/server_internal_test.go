@@ -2,18 +2,23 @@ package apiserver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"rest_api/internal/app/auth"
 	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
 	"rest_api/internal/app/store/teststore"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestServer_HandleCreateUser(t *testing.T) {
-	s := newServer(teststore.New())
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
 
 	testCases := []struct {
 		name string
@@ -68,7 +73,7 @@ func TestServer_HandleCreateUser(t *testing.T) {
 }
 
 func TestServer_HandleAuthorizeUser(t *testing.T) {
-	s := newServer(teststore.New())
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
 
 	testCases := []struct{
 		name string
@@ -94,7 +99,7 @@ func TestServer_HandleAuthorizeUser(t *testing.T) {
 				"email": "test@example.com",
 				"password": "",
 			},
-			expectedCode: http.StatusForbidden,
+			expectedCode: http.StatusUnauthorized,
 		},
 	}
 
@@ -121,7 +126,7 @@ func TestServer_HandleAuthorizeUser(t *testing.T) {
 			req, _ = http.NewRequest(http.MethodPost, "/authorize", b)
 			s.ServeHTTP(rec, req)
 
-			json.NewDecoder(rec.Body).Decode(resp)
+			json.NewDecoder(rec.Body).Decode(&resp)
 			recToken := resp.Token
 
 			assert.Equal(t, tc.expectedCode, rec.Code)
@@ -131,8 +136,6 @@ func TestServer_HandleAuthorizeUser(t *testing.T) {
 }
 
 func TestServer_HandleCreateArticle(t *testing.T) {
-	s := newServer(teststore.New())
-
 	testCases := []struct{
 		name string
 		payload interface{}
@@ -143,7 +146,6 @@ func TestServer_HandleCreateArticle(t *testing.T) {
 			payload: map[string]interface{}{
 				"article_header": "Test Article",
 				"article_text": "Article test text",
-				"author_id": 1,
 			},
 			expectedCode: http.StatusCreated,
 		},
@@ -157,14 +159,39 @@ func TestServer_HandleCreateArticle(t *testing.T) {
 			payload: map[string]interface{}{
 				"article_header": "Test Article",
 				"article_text": 7,
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown field",
+			payload: map[string]interface{}{
+				"article_header": "Test Article",
+				"article_text": "Article test text",
 				"author_id": 1,
 			},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name: "long header",
+			payload: map[string]interface{}{
+				"article_header": strings.Repeat("h", model.MaxHeadingLength + 1),
+				"article_text": "Article test text",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "empty text",
+			payload: map[string]interface{}{
+				"article_header": "Test Article",
+				"article_text": "",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
 			us := map[string]interface{}{
 				"name": "User",
 				"email": "user@mail.com",
@@ -182,7 +209,7 @@ func TestServer_HandleCreateArticle(t *testing.T) {
 			req, _ := http.NewRequest(http.MethodPost, "/create", b)
 			s.ServeHTTP(rec, req)
 
-			json.NewDecoder(rec.Body).Decode(resp)
+			json.NewDecoder(rec.Body).Decode(&resp)
 
 			token := fmt.Sprintf("Baerer %s", resp.Token)
 
@@ -199,9 +226,14 @@ func TestServer_HandleCreateArticle(t *testing.T) {
 
 func TestServer_HandleFindArticleByHeading(t *testing.T) {
 	ts := teststore.New()
-	ts.Article().CreateArticle(model.TestArticle(t, 5))
+	testAuthors(t, ts, 5)
+	ts.Article().CreateArticle(context.Background(), model.TestArticle(t, 5))
 
-	s := newServer(ts)
+	// Another author's private article with the same heading comes first.
+	ts.Article().CreateArticle(context.Background(), &model.Article{Heading: "Diary", Text: "secret notes", AuthorID: 4, Status: model.StatusPublished, Visibility: model.VisibilityPrivate})
+	ts.Article().CreateArticle(context.Background(), &model.Article{Heading: "Diary", Text: "public notes", AuthorID: 5, Status: model.StatusPublished})
+
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
 
 	testCases := []struct{
 		name string
@@ -215,12 +247,19 @@ func TestServer_HandleFindArticleByHeading(t *testing.T) {
 			},
 			expectedCode: http.StatusOK,
 		},
+		{
+			name: "heading shared with a private article",
+			payload: map[string]interface{}{
+				"article_heading": "Diary",
+			},
+			expectedCode: http.StatusOK,
+		},
 		{
 			name: "invalid article header",
 			payload: map[string]interface{}{
 				"article_heading": "Article",
 			},
-			expectedCode: http.StatusUnprocessableEntity,
+			expectedCode: http.StatusNotFound,
 		},
 		{
 			name: "invalid request",
@@ -246,66 +285,86 @@ func TestServer_HandleFindArticleByHeading(t *testing.T) {
 
 func TestServer_HandleChangeArticle(t *testing.T) {
 	ts := teststore.New()
-	article := model.TestArticle(t, 5)
-	ts.Article().CreateArticle(article)
-	s := newServer(ts)
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	owner, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	article := model.TestArticle(t, owner.ID)
+	ts.Article().CreateArticle(context.Background(), article)
 
 	testCases := []struct{
 		name string
+		token string
 		payload interface{}
 		expectedCode int
 	}{
 		{
 			name: "valid",
+			token: token,
 			payload: map[string]interface{}{
 				"id" : article.ID,
 				"article_header": "Updated TestArticle",
 				"article_text": "updated article text",
+				"version": 1,
 			},
 			expectedCode: http.StatusOK,
 		},
+		{
+			name: "stale version",
+			token: token,
+			payload: map[string]interface{}{
+				"id" : article.ID,
+				"article_header": "Stale TestArticle",
+				"article_text": "stale article text",
+				"version": 1,
+			},
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "missing version",
+			token: token,
+			payload: map[string]interface{}{
+				"id" : article.ID,
+				"article_header": "Blind TestArticle",
+				"article_text": "blind article text",
+			},
+			expectedCode: http.StatusPreconditionRequired,
+		},
 		{
 			name: "invalid",
+			token: token,
 			payload: "invalid",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name: "invalid id",
+			token: token,
 			payload: map[string]interface{}{
-				"id" : 1,
+				"id" : article.ID + 1,
 				"article_header": "Updated TestArticle",
 				"article_text": "updated article text",
 			},
-			expectedCode: http.StatusUnprocessableEntity,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name: "not owner",
+			token: otherToken,
+			payload: map[string]interface{}{
+				"id" : article.ID,
+				"article_header": "Stolen TestArticle",
+				"article_text": "stolen article text",
+			},
+			expectedCode: http.StatusForbidden,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			us := map[string]interface{}{
-				"name": "User",
-				"email": "user@mail.com",
-				"password": "123456",
-			}
-
-			resp := &struct {
-				User *model.User `json:"user"`
-				Token string `json:"token"`
-			}{}
-
 			b := &bytes.Buffer{}
-			json.NewEncoder(b).Encode(us)
-			rec := httptest.NewRecorder()
-			req, _ := http.NewRequest(http.MethodPost, "/create", b)
-			s.ServeHTTP(rec, req)
-
-			json.NewDecoder(rec.Body).Decode(resp)
-			token := fmt.Sprintf("Baerer %s", resp.Token)
-
 			json.NewEncoder(b).Encode(tc.payload)
-			req, _ = http.NewRequest(http.MethodPut, "/private/change/article", b)
-			rec = httptest.NewRecorder()
-			req.Header.Add("Authorization", token)
+			req, _ := http.NewRequest(http.MethodPut, "/private/change/article", b)
+			rec := httptest.NewRecorder()
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", tc.token))
 			s.ServeHTTP(rec, req)
 
 			assert.Equal(t, tc.expectedCode, rec.Code)
@@ -315,67 +374,606 @@ func TestServer_HandleChangeArticle(t *testing.T) {
 
 func TestServer_HandleDeleteArticle(t *testing.T) {
 	ts := teststore.New()
-	article := model.TestArticle(t, 5)
-	ts.Article().CreateArticle(article)
-	s := newServer(ts)
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	owner, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	article := model.TestArticle(t, owner.ID)
+	ts.Article().CreateArticle(context.Background(), article)
 
 	testCases := []struct{
 		name string
+		token string
 		payload interface{}
 		expectedCode int
 	}{
+		{
+			name: "not owner",
+			token: otherToken,
+			payload: map[string]interface{}{
+				"id" : article.ID,
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "missing version",
+			token: token,
+			payload: map[string]interface{}{
+				"id" : article.ID,
+			},
+			expectedCode: http.StatusPreconditionRequired,
+		},
+		{
+			name: "stale version",
+			token: token,
+			payload: map[string]interface{}{
+				"id" : article.ID,
+				"version": 2,
+			},
+			expectedCode: http.StatusPreconditionFailed,
+		},
 		{
 			name: "valid",
+			token: token,
 			payload: map[string]interface{}{
 				"id" : article.ID,
+				"version": 1,
 			},
 			expectedCode: http.StatusOK,
 		},
 		{
 			name: "invalid",
+			token: token,
 			payload: "invalid",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name: "invalid id",
+			token: token,
 			payload: map[string]interface{}{
-				"id" : 1,
+				"id" : article.ID + 1,
 			},
-			expectedCode: http.StatusUnprocessableEntity,
+			expectedCode: http.StatusNotFound,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			us := map[string]interface{}{
-				"name": "User",
-				"email": "user@mail.com",
-				"password": "123456",
-			}
-
-			resp := &struct {
-				User *model.User `json:"user"`
-				Token string `json:"token"`
-			}{}
-
 			b := &bytes.Buffer{}
-			json.NewEncoder(b).Encode(us)
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodDelete, "/private/delete/article", b)
 			rec := httptest.NewRecorder()
-			req, _ := http.NewRequest(http.MethodPost, "/create", b)
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", tc.token))
 			s.ServeHTTP(rec, req)
 
-			json.NewDecoder(rec.Body).Decode(resp)
-			token := fmt.Sprintf("Baerer %s", resp.Token)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
 
-			json.NewEncoder(b).Encode(tc.payload)
-			req, _ = http.NewRequest(http.MethodDelete, "/private/delete/article", b)
-			rec = httptest.NewRecorder()
-			req.Header.Add("Authorization", token)
-			s.ServeHTTP(rec, req)
+func TestServer_RoleAccess(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
 
+	owner, _ := testUserToken(t, s, "user@mail.com")
+	article := model.TestArticle(t, owner.ID)
+	ts.Article().CreateArticle(context.Background(), article)
+
+	reader := &model.User{Name: "Reader", Email: "reader@mail.com", Password: "123456", Role: model.RoleReader}
+	admin := &model.User{Name: "Admin", Email: "admin@mail.com", Password: "123456", Role: model.RoleAdmin}
+	ts.User().Create(context.Background(), reader)
+	ts.User().Create(context.Background(), admin)
+
+	sign := func(u *model.User) string {
+		tk, _, err := s.issueTokens(context.Background(), u, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return fmt.Sprintf("Bearer %s", tk)
+	}
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]interface{}{
+		"article_header": "Reader Article",
+		"article_text": "Reader text",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/private/create/article", b)
+	req.Header.Add("Authorization", sign(reader))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "forbidden")
+
+	json.NewEncoder(b).Encode(map[string]interface{}{"id": article.ID, "version": article.Version})
+	req, _ = http.NewRequest(http.MethodDelete, "/private/delete/article", b)
+	req.Header.Add("Authorization", sign(admin))
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_PrivateArticlesStayWithTheirAuthor(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+
+	owner, ownerToken := testUserToken(t, s, "user@mail.com")
+	article := &model.Article{Heading: "Diary", Text: "secret notes", AuthorID: owner.ID, Status: model.StatusPublished, Visibility: model.VisibilityPrivate}
+	ts.Article().CreateArticle(context.Background(), article)
+
+	editor := &model.User{Name: "Editor", Email: "editor@mail.com", Password: "123456", Role: model.RoleEditor}
+	ts.User().Create(context.Background(), editor)
+	token, _, err := s.issueTokens(context.Background(), editor, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := testRequest(s, http.MethodGet, fmt.Sprintf("/articles/%d", article.ID), token, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = testRequest(s, http.MethodGet, "/articles", token, nil)
+	assert.NotContains(t, rec.Body.String(), "Diary")
+
+	rec = testRequest(s, http.MethodGet, "/articles/search?q=secret", token, nil)
+	assert.NotContains(t, rec.Body.String(), "Diary")
+
+	rec = testRequest(s, http.MethodPut, "/private/change/article", token, map[string]interface{}{
+		"id": article.ID,
+		"article_header": "Diary",
+		"article_text": "edited",
+		"version": article.Version,
+	})
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret notes")
+
+	rec = testRequest(s, http.MethodGet, fmt.Sprintf("/articles/%d", article.ID), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_HandleCreateArticleUsesAuthenticatedAuthor(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+
+	// The author cannot be chosen by the client.
+	rec := testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Test Article",
+		"article_text": "Article test text",
+		"author_id": u.ID + 100,
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Test Article",
+		"article_text": "Article test text",
+	})
+
+	a := &model.Article{}
+	json.NewDecoder(rec.Body).Decode(a)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, u.ID, a.AuthorID)
+}
+
+func TestServer_HandleRefreshToken(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	tokens := struct {
+		Token string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}{}
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]interface{}{
+		"name": "User",
+		"email": "user@mail.com",
+		"password": "123456",
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/create", b)
+	s.ServeHTTP(rec, req)
+	json.NewDecoder(rec.Body).Decode(&tokens)
+
+	first := tokens.RefreshToken
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]interface{}{"refresh_token": token})
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/token/refresh", b)
+		s.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec = refresh(first)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(&tokens)
+	assert.NotEqual(t, first, tokens.RefreshToken)
+	assert.NotEmpty(t, tokens.Token)
+
+	second := tokens.RefreshToken
+
+	rec = refresh("unknown")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = refresh(first)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "refresh_token_reused")
+
+	rec = refresh(second)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_HandleLogout(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	tokens := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]interface{}{
+		"name": "User",
+		"email": "user@mail.com",
+		"password": "123456",
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/create", b)
+	s.ServeHTTP(rec, req)
+	json.NewDecoder(rec.Body).Decode(&tokens)
+
+	payload := map[string]interface{}{"refresh_token": tokens.RefreshToken}
+
+	json.NewEncoder(b).Encode(payload)
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/logout", b)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	json.NewEncoder(b).Encode(payload)
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/token/refresh", b)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_JwtAuthenticationExpiredToken(t *testing.T) {
+	config := NewConfig()
+	config.AccessTokenTTL = Duration{-time.Minute}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	resp := &struct {
+		Token string `json:"token"`
+	}{}
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]interface{}{
+		"name": "User",
+		"email": "user@mail.com",
+		"password": "123456",
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/create", b)
+	s.ServeHTTP(rec, req)
+	json.NewDecoder(rec.Body).Decode(resp)
+
+	json.NewEncoder(b).Encode(model.TestArticle(t, 1))
+	req, _ = http.NewRequest(http.MethodPost, "/private/create/article", b)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", resp.Token))
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "token_expired")
+}
+
+func TestServer_HandleJWKS(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	s.ServeHTTP(rec, req)
+
+	set := &auth.JWKSet{}
+	json.NewDecoder(rec.Body).Decode(set)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, "test", set.Keys[0].KeyID)
+}
+
+func TestServer_AuthorizeTokenIsAccepted(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+
+	user := map[string]interface{}{
+		"name": "User",
+		"email": "user@mail.com",
+		"password": "123456",
+	}
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(user)
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/create", b)
+	s.ServeHTTP(rec, req)
+
+	json.NewEncoder(b).Encode(map[string]interface{}{
+		"email": user["email"],
+		"password": user["password"],
+	})
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/authorize", b)
+	s.ServeHTTP(rec, req)
+
+	resp := &struct {
+		Token string `json:"token"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(resp)
+
+	json.NewEncoder(b).Encode(map[string]interface{}{
+		"article_header": "Test Article",
+		"article_text": "Article test text",
+	})
+	req, _ = http.NewRequest(http.MethodPost, "/private/create/article", b)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", resp.Token))
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+// testUserToken signs up a user with the given email through the API and
+// returns it together with its access token.
+func testUserToken(t *testing.T, s *server, email string) (*model.User, string) {
+	t.Helper()
+
+	resp := &struct {
+		User *model.User `json:"user"`
+		Token string `json:"token"`
+	}{}
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]interface{}{
+		"name": strings.Split(email, "@")[0],
+		"email": email,
+		"password": "123456",
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/create", b)
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create user %s: %d %s", email, rec.Code, rec.Body.String())
+	}
+
+	json.NewDecoder(rec.Body).Decode(resp)
+
+	return resp.User, resp.Token
+}
+
+// testAuthors creates n users directly in the store, so that articles can
+// be created for the author ids 1 to n.
+func testAuthors(t *testing.T, st store.Store, n int) {
+	t.Helper()
+
+	for i := 1; i <= n; i++ {
+		u := model.TestUser(t)
+		u.Name = fmt.Sprintf("author%d", i)
+		u.Email = fmt.Sprintf("author%d@example.com", i)
+
+		if err := st.User().Create(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testRequest sends the payload as JSON with an optional bearer token and
+// returns the recorded response.
+func testRequest(s *server, method string, url string, token string, payload interface{}) *httptest.ResponseRecorder {
+	b := &bytes.Buffer{}
+	if payload != nil {
+		json.NewEncoder(b).Encode(payload)
+	}
+
+	req, _ := http.NewRequest(method, url, b)
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestServer_HandleSearchArticles(t *testing.T) {
+	ts := teststore.New()
+	testAuthors(t, ts, 2)
+	for i, a := range []*model.Article{
+		{Heading: "REST API notes", Text: "How to design a rest api in Go", AuthorID: 1},
+		{Heading: "Shopping", Text: "Milk, bread and notebooks", AuthorID: 2},
+		{Heading: "Go tips", Text: "Small notes about the api of net/http and rest", AuthorID: 2},
+	} {
+		a.Heading = fmt.Sprintf("%s %d", a.Heading, i)
+		a.Status = model.StatusPublished
+		ts.Article().CreateArticle(context.Background(), a)
+	}
+
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	today := time.Now().UTC().Format("2006-01-02")
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+
+	testCases := []struct {
+		name string
+		query string
+		expectedCode int
+		expectedHeadings []string
+	}{
+		{"phrase", `?q="rest+api"`, http.StatusOK, []string{"REST API notes 0"}},
+		{"words", "?q=rest+api", http.StatusOK, []string{"REST API notes 0", "Go tips 2"}},
+		{"prefix", "?q=note*", http.StatusOK, []string{"REST API notes 0", "Shopping 1", "Go tips 2"}},
+		{"author", "?q=note*&author_id=2", http.StatusOK, []string{"Shopping 1", "Go tips 2"}},
+		{"limit", "?q=rest&limit=1", http.StatusOK, []string{"REST API notes 0"}},
+		{"date range", "?q=rest&from=" + today + "&to=" + today, http.StatusOK, []string{"REST API notes 0", "Go tips 2"}},
+		{"before range", "?q=rest&to=" + yesterday, http.StatusOK, []string{}},
+		{"empty query", "?q=", http.StatusBadRequest, nil},
+		{"invalid date", "?q=rest&from=yesterday", http.StatusBadRequest, nil},
+		{"invalid limit", "?q=rest&limit=1000", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(s, http.MethodGet, "/articles/search"+tc.query, "", nil)
 			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedHeadings == nil {
+				return
+			}
+
+			resp := &struct {
+				Results []*model.SearchResult `json:"results"`
+			}{}
+			json.NewDecoder(rec.Body).Decode(resp)
+
+			headings := make([]string, 0)
+			for _, res := range resp.Results {
+				headings = append(headings, res.Heading)
+			}
+
+			if tc.name == "prefix" {
+				assert.ElementsMatch(t, tc.expectedHeadings, headings)
+				return
+			}
+
+			assert.Equal(t, tc.expectedHeadings, headings)
 		})
 	}
 }
 
+func TestServer_HandleSearchArticlesHighlights(t *testing.T) {
+	ts := teststore.New()
+	testAuthors(t, ts, 1)
+	ts.Article().CreateArticle(context.Background(), &model.Article{Heading: "REST API", Text: "Notes on the rest api", AuthorID: 1, Status: model.StatusPublished})
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+
+	resp := &struct {
+		Results []*model.SearchResult `json:"results"`
+	}{}
+	rec := testRequest(s, http.MethodGet, `/articles/search?q="rest+api"`, "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, "<mark>REST</mark> <mark>API</mark>", resp.Results[0].HeadingHighlight)
+	assert.Equal(t, "Notes on the <mark>rest</mark> <mark>api</mark>", resp.Results[0].Snippet)
+	assert.Greater(t, resp.Results[0].Rank, 0.0)
+}
+
+func TestServer_HandleSearchArticlesEscapesHighlights(t *testing.T) {
+	ts := teststore.New()
+	testAuthors(t, ts, 1)
+	ts.Article().CreateArticle(context.Background(), &model.Article{Heading: "Formatting", Text: "Use <b>bold</b> sparingly", AuthorID: 1, Status: model.StatusPublished})
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+
+	resp := &struct {
+		Results []*model.SearchResult `json:"results"`
+	}{}
+	rec := testRequest(s, http.MethodGet, "/articles/search?q=bold", "", nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, "Use &lt;b&gt;<mark>bold</mark>&lt;/b&gt; sparingly", resp.Results[0].Snippet)
+}
+
+func TestServer_DatabaseTimeout(t *testing.T) {
+	config := NewConfig()
+	config.DatabaseTimeout = Duration{time.Nanosecond}
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	req, _ := http.NewRequest(http.MethodGet, "/articles", nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.Contains(t, rec.Body.String(), "database_timeout")
+
+	config.DatabaseTimeout = Duration{}
+	s = newServer(teststore.New(), auth.TestKeyManager(t), config)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_RequestValidation(t *testing.T) {
+	config := NewConfig()
+	config.MaxBodySize = 1024
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+	_, token := testUserToken(t, s, "user@mail.com")
+
+	p := &problem{}
+	rec := testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Two\nlines",
+		"article_text": " ",
+	})
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, p.Errors, "article_header")
+	assert.Contains(t, p.Errors, "article_text")
+
+	p = &problem{}
+	rec = testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Test Article",
+		"article_text": strings.Repeat("text ", 1024),
+	})
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "body_too_large", p.Code)
+
+	a := &model.Article{}
+	rec = testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Test Article",
+		"article_text": "Article test text",
+	})
+	json.NewDecoder(rec.Body).Decode(a)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	p = &problem{}
+	rec = testRequest(s, http.MethodPut, "/private/change/article", token, map[string]interface{}{
+		"id": a.ID,
+		"article_header": strings.Repeat("h", model.MaxHeadingLength + 1),
+		"article_text": "Article test text",
+		"version": a.Version,
+	})
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, p.Errors, "article_header")
+	assert.NotContains(t, p.Errors, "article_text")
+}
+
+func TestServer_DecodeSingleValue(t *testing.T) {
+	config := NewConfig()
+	config.MaxBodySize = 1024
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+	_, token := testUserToken(t, s, "user@mail.com")
 
+	testCases := []struct {
+		name string
+		body string
+		expectedCode int
+	}{
+		{"single value", `{"article_header": "One", "article_text": "text"}`, http.StatusCreated},
+		{"trailing whitespace", "{\"article_header\": \"Two\", \"article_text\": \"text\"}\n\t ", http.StatusCreated},
+		{"second value", `{"article_header": "Three", "article_text": "text"}{"article_header": "Four", "article_text": "text"}`, http.StatusBadRequest},
+		{"trailing garbage", `{"article_header": "Five", "article_text": "text"} garbage`, http.StatusBadRequest},
+		{"oversize trailing data", `{"article_header": "Six", "article_text": "text"}` + strings.Repeat(" ", 1024) + "{}", http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/private/create/article", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer " + token)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
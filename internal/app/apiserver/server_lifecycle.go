@@ -0,0 +1,99 @@
+package apiserver
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+// transitionRequest is the body accepted by all lifecycle endpoints. Only
+// publish reads publish_at and only the visibility endpoint reads
+// visibility.
+type transitionRequest struct {
+	Version int `json:"version"`
+	PublishAt *time.Time `json:"publish_at"`
+	Visibility string `json:"visibility"`
+}
+
+// handlePublishArticle publishes a draft or archived article right away, or
+// schedules a draft when publish_at lies in the future.
+func (s *server) handlePublishArticle() http.HandlerFunc {
+	return s.handleTransition(func(a *model.Article, req *transitionRequest) error {
+		now := time.Now().UTC()
+		at := now
+		if req.PublishAt != nil {
+			at = req.PublishAt.UTC()
+		}
+
+		return a.Publish(at, now)
+	})
+}
+
+func (s *server) handleUnpublishArticle() http.HandlerFunc {
+	return s.handleTransition(func(a *model.Article, req *transitionRequest) error {
+		return a.Unpublish()
+	})
+}
+
+func (s *server) handleArchiveArticle() http.HandlerFunc {
+	return s.handleTransition(func(a *model.Article, req *transitionRequest) error {
+		return a.Archive()
+	})
+}
+
+func (s *server) handleChangeVisibility() http.HandlerFunc {
+	return s.handleTransition(func(a *model.Article, req *transitionRequest) error {
+		return a.SetVisibility(req.Visibility)
+	})
+}
+
+// handleTransition loads the article from the {id} route variable, applies
+// the change to a copy of it and stores the result under the usual version
+// precondition.
+func (s *server) handleTransition(apply func(*model.Article, *transitionRequest) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &transitionRequest{}
+
+		// The body is optional here.
+		if err := s.decode(w, r, req); err != nil && !errors.Is(err, io.EOF) {
+			s.error(w, r, err)
+			return
+		}
+
+		a, ok := s.loadArticle(w, r, auth.ActionChangeArticle)
+		if !ok {
+			return
+		}
+
+		version, ok := s.checkVersion(w, r, a, req.Version)
+		if !ok {
+			return
+		}
+
+		changed := *a
+		changed.Version = version
+		a = &changed
+
+		if err := apply(a, req); err != nil {
+			s.error(w, r, invalid(err))
+			return
+		}
+
+		if err := s.store.Article().UpdateStatus(r.Context(), a); err != nil {
+			if err == store.ErrEditConflict {
+				s.writeConflict(w, r, a.ID)
+				return
+			}
+
+			s.error(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", articleETag(a))
+		s.respond(w, r, http.StatusOK, a)
+	}
+}
@@ -0,0 +1,125 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+func TestServer_ArticleLifecycle(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	_, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	rec := testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Lifecycle",
+		"article_text": "text",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	a := &model.Article{}
+	json.NewDecoder(rec.Body).Decode(a)
+	assert.Equal(t, model.StatusDraft, a.Status)
+	assert.Equal(t, model.VisibilityPublic, a.Visibility)
+
+	path := fmt.Sprintf("/articles/%d", a.ID)
+	listed := func(token string) bool {
+		rec := testRequest(s, http.MethodGet, "/articles", token, nil)
+		page := &articlePage{}
+		json.NewDecoder(rec.Body).Decode(page)
+
+		return len(page.Articles) == 1
+	}
+
+	transition := func(action string, token string, payload map[string]interface{}) int {
+		payload["version"] = a.Version
+		rec := testRequest(s, http.MethodPost, fmt.Sprintf("/private/articles/%d/%s", a.ID, action), token, payload)
+		if rec.Code == http.StatusOK {
+			json.NewDecoder(rec.Body).Decode(a)
+		}
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNotFound, testRequest(s, http.MethodGet, path, "", nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, http.MethodGet, path, otherToken, nil).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, http.MethodGet, path, token, nil).Code)
+	assert.False(t, listed(""))
+	assert.True(t, listed(token))
+
+	assert.Equal(t, http.StatusForbidden, transition("publish", otherToken, map[string]interface{}{}))
+	assert.Equal(t, http.StatusUnprocessableEntity, transition("unpublish", token, map[string]interface{}{}))
+	assert.Equal(t, http.StatusOK, transition("publish", token, map[string]interface{}{}))
+	assert.Equal(t, model.StatusPublished, a.Status)
+	assert.NotNil(t, a.PublishedAt)
+	assert.Equal(t, http.StatusOK, testRequest(s, http.MethodGet, path, "", nil).Code)
+	assert.True(t, listed(""))
+
+	rec = testRequest(s, http.MethodPut, fmt.Sprintf("/private/articles/%d/visibility", a.ID), token, map[string]interface{}{
+		"visibility": model.VisibilityUnlisted,
+		"version": a.Version,
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(a)
+	assert.Equal(t, http.StatusOK, testRequest(s, http.MethodGet, path, "", nil).Code)
+	assert.False(t, listed(""))
+
+	rec = testRequest(s, http.MethodPut, fmt.Sprintf("/private/articles/%d/visibility", a.ID), token, map[string]interface{}{
+		"visibility": "secret",
+		"version": a.Version,
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	assert.Equal(t, http.StatusOK, transition("archive", token, map[string]interface{}{}))
+	assert.Equal(t, http.StatusNotFound, testRequest(s, http.MethodGet, path, "", nil).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, transition("archive", token, map[string]interface{}{}))
+
+	rec = testRequest(s, http.MethodPost, fmt.Sprintf("/private/articles/%d/publish", a.ID), token, nil)
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+}
+
+func TestServer_ScheduledPublishing(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+
+	a := &model.Article{Heading: "Scheduled", Text: "text", AuthorID: u.ID}
+	ts.Article().CreateArticle(context.Background(), a)
+
+	at := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	rec := testRequest(s, http.MethodPost, fmt.Sprintf("/private/articles/%d/publish", a.ID), token, map[string]interface{}{
+		"publish_at": at,
+		"version": a.Version,
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	a, _ = ts.Article().Find(context.Background(), a.ID)
+	assert.Equal(t, model.StatusDraft, a.Status)
+	assert.True(t, at.Equal(*a.PublishAt))
+
+	p := newPublisher(ts, time.Minute)
+	n, err := p.runOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	p.now = func() time.Time {
+		return at.Add(time.Second)
+	}
+	n, err = p.runOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	a, _ = ts.Article().Find(context.Background(), a.ID)
+	assert.Equal(t, model.StatusPublished, a.Status)
+	assert.Nil(t, a.PublishAt)
+	assert.True(t, at.Equal(*a.PublishedAt))
+	assert.Equal(t, http.StatusOK, testRequest(s, http.MethodGet, fmt.Sprintf("/articles/%d", a.ID), "", nil).Code)
+}
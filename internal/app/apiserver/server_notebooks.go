@@ -0,0 +1,257 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"strconv"
+)
+
+var (
+	errDefaultNotebook = &requestError{http.StatusUnprocessableEntity, "default_notebook", errors.New("the default notebook cannot be deleted")}
+	errInvalidDeleteMode = badRequest(errors.New("mode must be either cascade or move"))
+	errForeignNotebook = forbidden("article can only be moved to a notebook of its author")
+)
+
+func (s *server) handleCreateNotebook() http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		n := &model.Notebook{
+			Name: req.Name,
+			OwnerID: principalFromContext(r.Context()).UserID,
+		}
+
+		if err := s.store.Notebook().Create(r.Context(), n); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, n)
+	}
+}
+
+func (s *server) handleShowNotebooks() http.HandlerFunc {
+	type response struct {
+		Notebooks []*model.Notebook `json:"notebooks"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ns, err := s.store.Notebook().FindByOwner(r.Context(), principalFromContext(r.Context()).UserID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{Notebooks: ns})
+	}
+}
+
+func (s *server) handleRenameNotebook() http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, ok := s.loadNotebook(w, r)
+		if !ok {
+			return
+		}
+
+		req := &request{}
+
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		if err := s.store.Notebook().Rename(r.Context(), n.ID, req.Name); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		n.Name = req.Name
+
+		s.respond(w, r, http.StatusOK, n)
+	}
+}
+
+// handleDeleteNotebook removes a notebook. With mode=cascade the articles in
+// it are moved to the trash; the default mode=move keeps them by moving them
+// to the owner's default notebook first. Either way the trashed articles end
+// up in the default notebook, since deleting a notebook deletes the rows left
+// in it for good.
+func (s *server) handleDeleteNotebook() http.HandlerFunc {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, ok := s.loadNotebook(w, r)
+		if !ok {
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = "move"
+		}
+
+		if mode != "move" && mode != "cascade" {
+			s.error(w, r, errInvalidDeleteMode)
+			return
+		}
+
+		if n.IsDefault {
+			s.error(w, r, errDefaultNotebook)
+			return
+		}
+
+		if err := s.store.WithTx(r.Context(), func(tx store.Store) error {
+			def, err := tx.Notebook().FindOrCreateDefault(r.Context(), n.OwnerID)
+			if err != nil {
+				return err
+			}
+
+			if mode == "cascade" {
+				err = tx.Article().TrashAllInNotebook(r.Context(), n.ID)
+			} else {
+				err = tx.Article().MoveAllToNotebook(r.Context(), n.ID, def.ID)
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Article().MoveTrashToNotebook(r.Context(), n.ID, def.ID); err != nil {
+				return err
+			}
+
+			return tx.Notebook().Delete(r.Context(), n.ID)
+		}); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{
+			Message: fmt.Sprintf("Deleted notebook: %s", n.Name),
+		})
+	}
+}
+
+func (s *server) handleShowNotebookArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, ok := s.loadNotebook(w, r)
+		if !ok {
+			return
+		}
+
+		f, err := articleFilter(r)
+		if err != nil {
+			s.error(w, r, badRequest(err))
+			return
+		}
+
+		f.NotebookID = n.ID
+		f.Audience = model.Audience{ViewerID: principalFromContext(r.Context()).UserID, All: true}
+
+		ars, next, err := s.store.Article().List(r.Context(), f)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respondPage(w, r, ars, next)
+	}
+}
+
+func (s *server) handleMoveArticle() http.HandlerFunc {
+	type request struct {
+		ID int `json:"id"`
+		NotebookID int `json:"notebook_id"`
+		Version int `json:"version"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		a, ok := s.authorizeArticle(w, r, auth.ActionChangeArticle, req.ID)
+		if !ok {
+			return
+		}
+
+		version, ok := s.checkVersion(w, r, a, req.Version)
+		if !ok {
+			return
+		}
+
+		n, err := s.store.Notebook().Find(r.Context(), req.NotebookID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		if n.OwnerID != a.AuthorID {
+			s.error(w, r, errForeignNotebook)
+			return
+		}
+
+		moved := *a
+		moved.NotebookID = n.ID
+		moved.Version = version
+
+		if err := s.store.Article().MoveToNotebook(r.Context(), &moved); err != nil {
+			if err == store.ErrEditConflict {
+				s.writeConflict(w, r, a.ID)
+				return
+			}
+
+			s.error(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", articleETag(&moved))
+		s.respond(w, r, http.StatusOK, &moved)
+	}
+}
+
+// loadNotebook finds the notebook named by the {id} route variable and checks
+// that the authenticated user may manage it. It writes the error response
+// itself and reports whether the handler should continue.
+func (s *server) loadNotebook(w http.ResponseWriter, r *http.Request) (*model.Notebook, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.error(w, r, badRequest(err))
+		return nil, false
+	}
+
+	n, err := s.store.Notebook().Find(r.Context(), id)
+	if err != nil {
+		s.error(w, r, err)
+		return nil, false
+	}
+
+	if !principalFromContext(r.Context()).CanManageNotebook(n) {
+		s.error(w, r, errForbidden)
+		return nil, false
+	}
+
+	return n, true
+}
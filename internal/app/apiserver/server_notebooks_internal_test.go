@@ -0,0 +1,182 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+func TestServer_HandleCreateNotebook(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	_, token := testUserToken(t, s, "user@mail.com")
+
+	testCases := []struct {
+		name string
+		payload interface{}
+		expectedCode int
+	}{
+		{
+			name: "valid",
+			payload: map[string]interface{}{"name": "Work"},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "invalid",
+			payload: "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "empty name",
+			payload: map[string]interface{}{"name": ""},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(s, http.MethodPost, "/private/notebooks", token, tc.payload)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_NotebookOwnership(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	_, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	n := &model.Notebook{}
+	rec := testRequest(s, http.MethodPost, "/private/notebooks", token, map[string]interface{}{"name": "Work"})
+	json.NewDecoder(rec.Body).Decode(n)
+
+	url := fmt.Sprintf("/private/notebooks/%d", n.ID)
+
+	rec = testRequest(s, http.MethodGet, url+"/articles", otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(s, http.MethodPut, url, otherToken, map[string]interface{}{"name": "Mine"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(s, http.MethodPost, "/private/create/article", otherToken, map[string]interface{}{
+		"article_header": "Other",
+		"article_text": "Other text",
+		"notebook_id": n.ID,
+	})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(s, http.MethodPut, url, token, map[string]interface{}{"name": "Job"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	resp := &struct {
+		Notebooks []*model.Notebook `json:"notebooks"`
+	}{}
+	rec = testRequest(s, http.MethodGet, "/private/notebooks", otherToken, nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, resp.Notebooks, 0)
+
+	rec = testRequest(s, http.MethodGet, "/private/notebooks", token, nil)
+	json.NewDecoder(rec.Body).Decode(resp)
+	assert.Len(t, resp.Notebooks, 1)
+	assert.Equal(t, "Job", resp.Notebooks[0].Name)
+}
+
+func TestServer_HandleDeleteNotebook(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+
+	create := func(name string) *model.Notebook {
+		n := &model.Notebook{}
+		rec := testRequest(s, http.MethodPost, "/private/notebooks", token, map[string]interface{}{"name": name})
+		json.NewDecoder(rec.Body).Decode(n)
+
+		a := model.TestArticle(t, u.ID)
+		a.Heading = name
+		a.NotebookID = n.ID
+		ts.Article().CreateArticle(context.Background(), a)
+
+		return n
+	}
+
+	moved := create("Moved")
+	cascaded := create("Cascaded")
+
+	rec := testRequest(s, http.MethodDelete, fmt.Sprintf("/private/notebooks/%d?mode=drop", moved.ID), token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = testRequest(s, http.MethodDelete, fmt.Sprintf("/private/notebooks/%d", moved.ID), token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(s, http.MethodDelete, fmt.Sprintf("/private/notebooks/%d?mode=cascade", cascaded.ID), token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	def, err := ts.Notebook().FindOrCreateDefault(context.Background(), u.ID)
+	assert.NoError(t, err)
+
+	ars, _, err := ts.Article().List(context.Background(), &model.ArticleFilter{NotebookID: def.ID})
+	assert.NoError(t, err)
+	assert.Len(t, ars, 1)
+	assert.Equal(t, "Moved", ars[0].Heading)
+
+	// Cascaded articles go to the trash rather than away for good.
+	trash, err := ts.Article().ListTrash(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, "Cascaded", trash[0].Heading)
+	assert.Equal(t, def.ID, trash[0].NotebookID)
+
+	rec = testRequest(s, http.MethodDelete, fmt.Sprintf("/private/notebooks/%d", def.ID), token, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestServer_HandleMoveArticle(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	a := model.TestArticle(t, u.ID)
+	ts.Article().CreateArticle(context.Background(), a)
+
+	n := &model.Notebook{}
+	rec := testRequest(s, http.MethodPost, "/private/notebooks", token, map[string]interface{}{"name": "Work"})
+	json.NewDecoder(rec.Body).Decode(n)
+
+	foreign := &model.Notebook{}
+	rec = testRequest(s, http.MethodPost, "/private/notebooks", otherToken, map[string]interface{}{"name": "Foreign"})
+	json.NewDecoder(rec.Body).Decode(foreign)
+
+	testCases := []struct {
+		name string
+		token string
+		notebookID int
+		expectedCode int
+	}{
+		{"not owner", otherToken, foreign.ID, http.StatusForbidden},
+		{"foreign notebook", token, foreign.ID, http.StatusForbidden},
+		{"unknown notebook", token, 100, http.StatusNotFound},
+		{"valid", token, n.ID, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(s, http.MethodPut, "/private/move/article", tc.token, map[string]interface{}{
+				"id": a.ID,
+				"notebook_id": tc.notebookID,
+				"version": a.Version,
+			})
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	ars, _, _ := ts.Article().List(context.Background(), &model.ArticleFilter{NotebookID: n.ID})
+	assert.Len(t, ars, 1)
+}
@@ -0,0 +1,204 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/textdiff"
+	"strconv"
+)
+
+var errInvalidRevision = badRequest(errors.New("revision must be a positive number"))
+
+// Revision history is part of editing an article, so every endpoint here
+// requires the same permission as changing it.
+
+func (s *server) handleShowRevisions() http.HandlerFunc {
+	type response struct {
+		Revisions []*model.Revision `json:"revisions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := s.loadArticle(w, r, auth.ActionChangeArticle)
+		if !ok {
+			return
+		}
+
+		revs, err := s.store.Revision().FindByArticle(r.Context(), a.ID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{Revisions: revs})
+	}
+}
+
+func (s *server) handleFindRevision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := s.loadArticle(w, r, auth.ActionChangeArticle)
+		if !ok {
+			return
+		}
+
+		rev, ok := s.loadRevision(w, r, a.ID, mux.Vars(r)["revision"])
+		if !ok {
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, rev)
+	}
+}
+
+// handleRestoreRevision writes the content of an old revision back to the
+// article. The restore is recorded as a new revision, so history is never
+// rewritten.
+func (s *server) handleRestoreRevision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := s.loadArticle(w, r, auth.ActionChangeArticle)
+		if !ok {
+			return
+		}
+
+		rev, ok := s.loadRevision(w, r, a.ID, mux.Vars(r)["revision"])
+		if !ok {
+			return
+		}
+
+		// Restoring is a POST, so a precondition is honoured but not required.
+		restored := *a
+		if r.Header.Get("If-Match") != "" {
+			version, ok := s.checkVersion(w, r, a, 0)
+			if !ok {
+				return
+			}
+
+			restored.Version = version
+		}
+
+		restored.Heading = rev.Heading
+		restored.Text = rev.Text
+		a = &restored
+
+		if err := s.store.WithTx(r.Context(), func(tx store.Store) error {
+			if err := tx.Article().ChangeArticleById(r.Context(), a); err != nil {
+				return err
+			}
+
+			return tx.Revision().Create(r.Context(), model.NewRevision(a, principalFromContext(r.Context()).UserID))
+		}); err != nil {
+			if err == store.ErrEditConflict {
+				s.writeConflict(w, r, a.ID)
+				return
+			}
+
+			s.error(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", articleETag(a))
+		s.respond(w, r, http.StatusOK, a)
+	}
+}
+
+// handleDiffRevisions returns a unified diff of the article text between the
+// revisions given by the from and to query parameters. By default it compares
+// the latest revision with the one before it.
+func (s *server) handleDiffRevisions() http.HandlerFunc {
+	type response struct {
+		From int `json:"from"`
+		To int `json:"to"`
+		FromHeading string `json:"from_heading"`
+		ToHeading string `json:"to_heading"`
+		Diff string `json:"diff"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := s.loadArticle(w, r, auth.ActionChangeArticle)
+		if !ok {
+			return
+		}
+
+		q := r.URL.Query()
+
+		var to *model.Revision
+		if q.Get("to") != "" {
+			to, ok = s.loadRevision(w, r, a.ID, q.Get("to"))
+			if !ok {
+				return
+			}
+		} else {
+			revs, err := s.store.Revision().FindByArticle(r.Context(), a.ID)
+			if err != nil {
+				s.error(w, r, err)
+				return
+			}
+
+			if len(revs) == 0 {
+				s.error(w, r, store.ErrRecordNotFound)
+				return
+			}
+
+			to = revs[len(revs)-1]
+		}
+
+		from := &model.Revision{}
+		if q.Get("from") != "" {
+			from, ok = s.loadRevision(w, r, a.ID, q.Get("from"))
+			if !ok {
+				return
+			}
+		} else if to.Number > 1 {
+			from, ok = s.loadRevision(w, r, a.ID, strconv.Itoa(to.Number-1))
+			if !ok {
+				return
+			}
+		}
+
+		s.respond(w, r, http.StatusOK, &response{
+			From: from.Number,
+			To: to.Number,
+			FromHeading: from.Heading,
+			ToHeading: to.Heading,
+			Diff: textdiff.Unified(
+				fmt.Sprintf("revision %d", from.Number),
+				fmt.Sprintf("revision %d", to.Number),
+				from.Text,
+				to.Text,
+				textdiff.DefaultContext,
+			),
+		})
+	}
+}
+
+// loadArticle is authorizeArticle for routes that carry the article ID in the
+// {id} route variable.
+func (s *server) loadArticle(w http.ResponseWriter, r *http.Request, action auth.Action) (*model.Article, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.error(w, r, badRequest(err))
+		return nil, false
+	}
+
+	return s.authorizeArticle(w, r, action, id)
+}
+
+func (s *server) loadRevision(w http.ResponseWriter, r *http.Request, articleID int, number string) (*model.Revision, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		s.error(w, r, errInvalidRevision)
+		return nil, false
+	}
+
+	rev, err := s.store.Revision().Find(r.Context(), articleID, n)
+	if err != nil {
+		s.error(w, r, err)
+		return nil, false
+	}
+
+	return rev, true
+}
@@ -0,0 +1,84 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+func TestServer_ArticleRevisions(t *testing.T) {
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	_, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	a := &model.Article{}
+	rec := testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Draft",
+		"article_text": "first line\nsecond line\n",
+	})
+	json.NewDecoder(rec.Body).Decode(a)
+
+	for i, text := range []string{"first line\n2nd line\n", "first line\n2nd line\nthird line\n"} {
+		rec = testRequest(s, http.MethodPut, "/private/change/article", token, map[string]interface{}{
+			"id": a.ID,
+			"article_header": "Final",
+			"article_text": text,
+			"version": a.Version + i,
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	url := fmt.Sprintf("/private/articles/%d", a.ID)
+
+	revs := &struct {
+		Revisions []*model.Revision `json:"revisions"`
+	}{}
+	rec = testRequest(s, http.MethodGet, url+"/revisions", token, nil)
+	json.NewDecoder(rec.Body).Decode(revs)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, revs.Revisions, 3)
+
+	rec = testRequest(s, http.MethodGet, url+"/revisions", otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rev := &model.Revision{}
+	rec = testRequest(s, http.MethodGet, url+"/revisions/1", token, nil)
+	json.NewDecoder(rec.Body).Decode(rev)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Draft", rev.Heading)
+
+	rec = testRequest(s, http.MethodGet, url+"/revisions/9", token, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	diff := &struct {
+		From int `json:"from"`
+		To int `json:"to"`
+		Diff string `json:"diff"`
+	}{}
+	rec = testRequest(s, http.MethodGet, url+"/diff?from=1&to=3", token, nil)
+	json.NewDecoder(rec.Body).Decode(diff)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "--- revision 1\n+++ revision 3\n@@ -1,2 +1,3 @@\n first line\n-second line\n+2nd line\n+third line\n", diff.Diff)
+
+	rec = testRequest(s, http.MethodGet, url+"/diff", token, nil)
+	json.NewDecoder(rec.Body).Decode(diff)
+	assert.Equal(t, 2, diff.From)
+	assert.Equal(t, 3, diff.To)
+
+	rec = testRequest(s, http.MethodPost, url+"/revisions/1/restore", token, nil)
+	restored := &model.Article{}
+	json.NewDecoder(rec.Body).Decode(restored)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Draft", restored.Heading)
+	assert.Equal(t, "first line\nsecond line\n", restored.Text)
+
+	rec = testRequest(s, http.MethodGet, url+"/revisions/4", token, nil)
+	json.NewDecoder(rec.Body).Decode(rev)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Draft", rev.Heading)
+}
@@ -0,0 +1,144 @@
+package apiserver
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"strconv"
+)
+
+var (
+	errTagExists = &requestError{http.StatusConflict, "tag_exists", errors.New("tag with this name already exists, merge the tags instead")}
+	errMergeIntoSelf = &requestError{http.StatusUnprocessableEntity, "merge_into_self", errors.New("a tag cannot be merged into itself")}
+)
+
+func (s *server) handleShowTags() http.HandlerFunc {
+	type response struct {
+		Tags []*model.Tag `json:"tags"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ts, err := s.store.Tag().FindByOwner(r.Context(), principalFromContext(r.Context()).UserID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{Tags: ts})
+	}
+}
+
+func (s *server) handleRenameTag() http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := s.loadTag(w, r, mux.Vars(r)["id"])
+		if !ok {
+			return
+		}
+
+		req := &request{}
+
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		if err := s.store.Tag().Rename(r.Context(), t.ID, req.Name); err != nil {
+			if err == store.ErrAlreadyExists {
+				s.error(w, r, errTagExists)
+				return
+			}
+
+			s.error(w, r, err)
+			return
+		}
+
+		t, err := s.store.Tag().Find(r.Context(), t.ID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, t)
+	}
+}
+
+// handleMergeTag moves all articles of the tag in the path over to the tag
+// given as "into" and removes the former. Both tags must belong to the same
+// owner.
+func (s *server) handleMergeTag() http.HandlerFunc {
+	type request struct {
+		Into int `json:"into"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, ok := s.loadTag(w, r, mux.Vars(r)["id"])
+		if !ok {
+			return
+		}
+
+		req := &request{}
+
+		if err := s.decode(w, r, req); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		if req.Into == from.ID {
+			s.error(w, r, errMergeIntoSelf)
+			return
+		}
+
+		into, ok := s.loadTag(w, r, strconv.Itoa(req.Into))
+		if !ok {
+			return
+		}
+
+		if into.OwnerID != from.OwnerID {
+			s.error(w, r, errForbidden)
+			return
+		}
+
+		if err := s.store.Tag().Merge(r.Context(), from.ID, into.ID); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		into, err := s.store.Tag().Find(r.Context(), into.ID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, into)
+	}
+}
+
+// loadTag finds the tag with the given id and checks that the authenticated
+// user may manage it. It writes the error response itself and reports whether
+// the handler should continue.
+func (s *server) loadTag(w http.ResponseWriter, r *http.Request, rawID string) (*model.Tag, bool) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		s.error(w, r, badRequest(err))
+		return nil, false
+	}
+
+	t, err := s.store.Tag().Find(r.Context(), id)
+	if err != nil {
+		s.error(w, r, err)
+		return nil, false
+	}
+
+	if !principalFromContext(r.Context()).CanManageTag(t) {
+		s.error(w, r, errForbidden)
+		return nil, false
+	}
+
+	return t, true
+}
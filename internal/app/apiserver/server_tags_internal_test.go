@@ -0,0 +1,180 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+)
+
+func TestServer_ArticleTags(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	_, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	for _, tc := range []struct {
+		token string
+		heading string
+		tags []string
+	}{
+		{token, "Standup", []string{"Work", "meetings"}},
+		{token, "Groceries", []string{"home"}},
+		{token, "Budget", []string{"work", "home", "work"}},
+		{otherToken, "Sprint", []string{"work"}},
+	} {
+		rec := testRequest(s, http.MethodPost, "/private/create/article", tc.token, map[string]interface{}{
+			"article_header": tc.heading,
+			"article_text": "text",
+			"tags": tc.tags,
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		a := &model.Article{}
+		json.NewDecoder(rec.Body).Decode(a)
+		rec = testRequest(s, http.MethodPost, fmt.Sprintf("/private/articles/%d/publish", a.ID), tc.token, map[string]interface{}{
+			"version": a.Version,
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	rec := testRequest(s, http.MethodPost, "/private/create/article", token, map[string]interface{}{
+		"article_header": "Untagged",
+		"article_text": "text",
+		"tags": []string{" "},
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	tags := func(token string) map[string]int {
+		rec := testRequest(s, http.MethodGet, "/private/tags", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		resp := &struct {
+			Tags []*model.Tag `json:"tags"`
+		}{}
+		json.NewDecoder(rec.Body).Decode(resp)
+
+		counts := make(map[string]int)
+		for _, tag := range resp.Tags {
+			counts[tag.Name] = tag.ArticleCount
+		}
+
+		return counts
+	}
+
+	assert.Equal(t, map[string]int{"work": 2, "meetings": 1, "home": 2}, tags(token))
+	assert.Equal(t, map[string]int{"work": 1}, tags(otherToken))
+
+	headings := func(query string) []string {
+		rec := testRequest(s, http.MethodGet, "/articles?sort=heading"+query, "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		page := &articlePage{}
+		json.NewDecoder(rec.Body).Decode(page)
+
+		result := make([]string, 0)
+		for _, a := range page.Articles {
+			result = append(result, a.Heading)
+		}
+
+		return result
+	}
+
+	assert.Equal(t, []string{"Budget", "Sprint", "Standup"}, headings("&tag=work"))
+	assert.Equal(t, []string{"Budget"}, headings("&tag=work&tag=home"))
+	assert.Equal(t, []string{"Budget", "Groceries", "Sprint", "Standup"}, headings("&tag=work&tag=home&tag_mode=any"))
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, http.MethodGet, "/articles?tag_mode=some", "", nil).Code)
+
+	a, _ := ts.Article().FindByHeading(context.Background(), "Standup", model.Audience{All: true})
+	assert.Equal(t, []string{"meetings", "work"}, a.Tags)
+
+	rec = testRequest(s, http.MethodPut, "/private/change/article", token, map[string]interface{}{
+		"id": a.ID,
+		"article_header": a.Heading,
+		"article_text": "changed",
+		"version": a.Version,
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	a, _ = ts.Article().FindByHeading(context.Background(), "Standup", model.Audience{All: true})
+	assert.Equal(t, []string{"meetings", "work"}, a.Tags)
+
+	rec = testRequest(s, http.MethodPut, "/private/change/article", token, map[string]interface{}{
+		"id": a.ID,
+		"article_header": a.Heading,
+		"article_text": "changed",
+		"version": a.Version,
+		"tags": []string{},
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	a, _ = ts.Article().FindByHeading(context.Background(), "Standup", model.Audience{All: true})
+	assert.Empty(t, a.Tags)
+}
+
+func TestServer_RenameAndMergeTags(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+	other, otherToken := testUserToken(t, s, "other@mail.com")
+
+	a := &model.Article{Heading: "One", Text: "text", AuthorID: u.ID}
+	b := &model.Article{Heading: "Two", Text: "text", AuthorID: u.ID}
+	ts.Article().CreateArticle(context.Background(), a)
+	ts.Article().CreateArticle(context.Background(), b)
+	ts.Tag().SetArticleTags(context.Background(), a.ID, u.ID, []string{"job", "work"})
+	ts.Tag().SetArticleTags(context.Background(), b.ID, u.ID, []string{"job"})
+
+	o := &model.Article{Heading: "Three", Text: "text", AuthorID: other.ID}
+	ts.Article().CreateArticle(context.Background(), o)
+	ts.Tag().SetArticleTags(context.Background(), o.ID, other.ID, []string{"work"})
+
+	tagID := func(ownerID int, name string) int {
+		tags, _ := ts.Tag().FindByOwner(context.Background(), ownerID)
+		for _, tag := range tags {
+			if tag.Name == name {
+				return tag.ID
+			}
+		}
+
+		return 0
+	}
+
+	job, work, otherWork := tagID(u.ID, "job"), tagID(u.ID, "work"), tagID(other.ID, "work")
+
+	testCases := []struct {
+		name string
+		method string
+		path string
+		token string
+		payload interface{}
+		expectedCode int
+	}{
+		{"rename onto existing", http.MethodPut, fmt.Sprintf("/private/tags/%d", job), token, map[string]string{"name": "Work"}, http.StatusConflict},
+		{"rename foreign", http.MethodPut, fmt.Sprintf("/private/tags/%d", job), otherToken, map[string]string{"name": "career"}, http.StatusForbidden},
+		{"rename invalid", http.MethodPut, fmt.Sprintf("/private/tags/%d", job), token, map[string]string{"name": ""}, http.StatusUnprocessableEntity},
+		{"rename missing", http.MethodPut, "/private/tags/100", token, map[string]string{"name": "career"}, http.StatusNotFound},
+		{"merge into self", http.MethodPost, fmt.Sprintf("/private/tags/%d/merge", job), token, map[string]int{"into": job}, http.StatusUnprocessableEntity},
+		{"merge into foreign", http.MethodPost, fmt.Sprintf("/private/tags/%d/merge", job), token, map[string]int{"into": otherWork}, http.StatusForbidden},
+		{"merge", http.MethodPost, fmt.Sprintf("/private/tags/%d/merge", job), token, map[string]int{"into": work}, http.StatusOK},
+		{"rename", http.MethodPut, fmt.Sprintf("/private/tags/%d", work), token, map[string]string{"name": "Career"}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(s, tc.method, tc.path, tc.token, tc.payload)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	tags, _ := ts.Tag().FindByOwner(context.Background(), u.ID)
+	assert.Len(t, tags, 1)
+	assert.Equal(t, "career", tags[0].Name)
+	assert.Equal(t, 2, tags[0].ArticleCount)
+
+	tags, _ = ts.Tag().FindByOwner(context.Background(), other.ID)
+	assert.Equal(t, "work", tags[0].Name)
+}
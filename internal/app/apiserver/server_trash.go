@@ -0,0 +1,74 @@
+package apiserver
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"strconv"
+)
+
+// handleShowTrash lists the caller's trashed articles. They stay there until
+// they are restored, the trash is emptied or the retention period is over.
+func (s *server) handleShowTrash() http.HandlerFunc {
+	type response struct {
+		Articles []*model.Article `json:"articles"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ars, err := s.store.Article().ListTrash(r.Context(), principalFromContext(r.Context()).UserID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{Articles: ars})
+	}
+}
+
+func (s *server) handleRestoreArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, badRequest(err))
+			return
+		}
+
+		a, err := s.store.Article().FindTrashed(r.Context(), id)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		// Whoever was allowed to delete the article may bring it back.
+		if !principalFromContext(r.Context()).Can(auth.ActionDeleteArticle, a) {
+			s.error(w, r, errForbidden)
+			return
+		}
+
+		if err := s.store.Article().Restore(r.Context(), a); err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", articleETag(a))
+		s.respond(w, r, http.StatusOK, a)
+	}
+}
+
+// handleEmptyTrash permanently deletes all of the caller's trashed articles.
+func (s *server) handleEmptyTrash() http.HandlerFunc {
+	type response struct {
+		Deleted int `json:"deleted"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, err := s.store.Article().EmptyTrash(r.Context(), principalFromContext(r.Context()).UserID)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{Deleted: n})
+	}
+}
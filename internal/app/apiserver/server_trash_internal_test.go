@@ -0,0 +1,102 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+func TestServer_Trash(t *testing.T) {
+	ts := teststore.New()
+	s := newServer(ts, auth.TestKeyManager(t), NewConfig())
+	u, token := testUserToken(t, s, "user@mail.com")
+	_, otherToken := testUserToken(t, s, "other@mail.com")
+
+	a := model.TestArticle(t, u.ID)
+	ts.Article().CreateArticle(context.Background(), a)
+	path := fmt.Sprintf("/articles/%d", a.ID)
+
+	trash := func(token string) []*model.Article {
+		rec := testRequest(s, http.MethodGet, "/private/trash", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		resp := &struct {
+			Articles []*model.Article `json:"articles"`
+		}{}
+		json.NewDecoder(rec.Body).Decode(resp)
+
+		return resp.Articles
+	}
+
+	trashArticle := func() {
+		current, err := ts.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+
+		rec := testRequest(s, http.MethodDelete, "/private/delete/article", token, map[string]interface{}{
+			"id": a.ID,
+			"version": current.Version,
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	trashArticle()
+	assert.Equal(t, http.StatusNotFound, testRequest(s, http.MethodGet, path, "", nil).Code)
+
+	rec := testRequest(s, http.MethodGet, "/articles", "", nil)
+	page := &articlePage{}
+	json.NewDecoder(rec.Body).Decode(page)
+	assert.Empty(t, page.Articles)
+
+	trashed := trash(token)
+	assert.Len(t, trashed, 1)
+	assert.NotNil(t, trashed[0].DeletedAt)
+	assert.Empty(t, trash(otherToken))
+
+	restorePath := fmt.Sprintf("/private/trash/%d/restore", a.ID)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, http.MethodPost, restorePath, otherToken, nil).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, http.MethodPost, restorePath, token, nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, http.MethodPost, restorePath, token, nil).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, http.MethodGet, path, "", nil).Code)
+	assert.Empty(t, trash(token))
+
+	trashArticle()
+	rec = testRequest(s, http.MethodDelete, "/private/trash", otherToken, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, trash(token), 1)
+
+	rec = testRequest(s, http.MethodDelete, "/private/trash", token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"deleted": 1}`, rec.Body.String())
+	assert.Empty(t, trash(token))
+	assert.Equal(t, http.StatusNotFound, testRequest(s, http.MethodPost, restorePath, token, nil).Code)
+}
+
+func TestServer_TrashPurger(t *testing.T) {
+	ts := teststore.New()
+	testAuthors(t, ts, 1)
+	a := model.TestArticle(t, 1)
+	ts.Article().CreateArticle(context.Background(), a)
+	ts.Article().DeleteArticle(context.Background(), a.ID, a.Version)
+
+	p := newPurger(ts, time.Hour, 24 * time.Hour)
+	n, err := p.runOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	p.now = func() time.Time {
+		return time.Now().Add(25 * time.Hour)
+	}
+	n, err = p.runOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = ts.Article().FindTrashed(context.Background(), a.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const instrumentationName = "rest_api/internal/app/apiserver"
+
+// newTracerProvider builds the provider that exports spans as configured by
+// config.TracingExporter: "stdout" writes them to standard output and "otlp"
+// sends them to the collector at config.OTLPEndpoint over HTTP. Without an
+// exporter tracing is off and the provider is nil.
+func newTracerProvider(ctx context.Context, config *Config) (*sdktrace.TracerProvider, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		err error
+	)
+
+	switch config.TracingExporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		exporter, err = newOTLPExporter(ctx, config.OTLPEndpoint)
+	default:
+		err = fmt.Errorf("unknown tracing exporter %q", config.TracingExporter)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(config.ServiceName))
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.TraceSampleRatio))),
+	), nil
+}
+
+// newOTLPExporter sends spans to the OTLP/HTTP endpoint, given as a URL such
+// as http://localhost:4318. The path defaults to /v1/traces.
+func newOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid otlp endpoint %q", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if strings.Trim(u.Path, "/") != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// traceRequests starts a server span for every request, continuing the trace
+// of the traceparent header when the client sent one. The span is named after
+// the method and the template of the route, like the metrics, and its trace
+// context is sent back in the traceparent header of the response.
+func (s *server) traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeTemplate(r)
+
+		ctx, span := s.tracer.Start(ctx, r.Method + " " + route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		s.propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCode(rw.status))
+
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package apiserver
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/store/teststore"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestServer_TraceRequests(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	s.tracer = tp.Tracer(instrumentationName)
+
+	_, token := testUserToken(t, s, "user@mail.com")
+
+	req, _ := http.NewRequest(http.MethodGet, "/articles/100", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+
+	rec = testRequest(s, http.MethodGet, "/private/notebooks", token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	spans := testSpans(recorder)
+
+	span := spans["GET /articles/{id:[0-9]+}"]
+	require.NotNil(t, span)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+	assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", http.StatusNotFound))
+	assert.Contains(t, span.Attributes(), attribute.String("http.route", "/articles/{id:[0-9]+}"))
+
+	span = spans["GET /private/notebooks"]
+	require.NotNil(t, span)
+	assert.False(t, span.Parent().IsValid())
+
+	jwtSpan := spans["jwt.Parse"]
+	require.NotNil(t, jwtSpan)
+	assert.Equal(t, span.SpanContext().SpanID(), jwtSpan.Parent().SpanID())
+
+	// The rate limiter and authentication share one parse of the token.
+	parses := 0
+	for _, ended := range recorder.Ended() {
+		if ended.Name() == "jwt.Parse" {
+			parses++
+		}
+	}
+	assert.Equal(t, 1, parses)
+
+	assert.NotNil(t, spans["POST /create"])
+}
+
+func TestServer_TraceRequests_Unmatched(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := newServer(teststore.New(), auth.TestKeyManager(t), NewConfig())
+	s.tracer = tp.Tracer(instrumentationName)
+
+	testRequest(s, http.MethodGet, "/wp-admin/setup.php", "", nil)
+
+	assert.NotNil(t, testSpans(recorder)["GET " + routeUnmatched])
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	config := NewConfig()
+
+	tp, err := newTracerProvider(context.Background(), config)
+	assert.NoError(t, err)
+	assert.Nil(t, tp)
+
+	config.TracingExporter = "jaeger"
+	_, err = newTracerProvider(context.Background(), config)
+	assert.Error(t, err)
+
+	config.TracingExporter = "otlp"
+	config.OTLPEndpoint = "localhost"
+	_, err = newTracerProvider(context.Background(), config)
+	assert.Error(t, err)
+
+	config.TracingExporter = "stdout"
+	tp, err = newTracerProvider(context.Background(), config)
+	assert.NoError(t, err)
+	assert.NoError(t, tp.Shutdown(context.Background()))
+}
+
+func TestNewTracerProvider_OTLP(t *testing.T) {
+	collector := &testCollector{}
+	ts := httptest.NewServer(collector)
+	defer ts.Close()
+
+	config := NewConfig()
+	config.ServiceName = "notebook-test"
+	config.TracingExporter = "otlp"
+	config.OTLPEndpoint = ts.URL
+
+	tp, err := newTracerProvider(context.Background(), config)
+	require.NoError(t, err)
+
+	s := newServer(teststore.New(), auth.TestKeyManager(t), config)
+	s.tracer = tp.Tracer(instrumentationName)
+
+	req, _ := http.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Shutting down exports the spans still waiting in the batch.
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	assert.Equal(t, "/v1/traces", collector.path)
+	assert.Equal(t, []string{"GET /hello"}, collector.spanNames())
+	assert.Equal(t, "notebook-test", collector.serviceName())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", collector.traceID())
+}
+
+// testCollector stands in for an OpenTelemetry collector that receives spans
+// over OTLP/HTTP.
+type testCollector struct {
+	mu sync.Mutex
+	path string
+	requests []*collectortrace.ExportTraceServiceRequest
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := &collectortrace.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	c.path = r.URL.Path
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+
+	resp, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(resp)
+}
+
+func (c *testCollector) spanNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := []string{}
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					names = append(names, span.Name)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+func (c *testCollector) serviceName() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, attr := range rs.Resource.Attributes {
+				if attr.Key == "service.name" {
+					return attr.Value.GetStringValue()
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func (c *testCollector) traceID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					return trace.TraceID(span.TraceId).String()
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// testSpans returns the ended spans by name.
+func testSpans(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	return spans
+}
@@ -0,0 +1,134 @@
+package apiserver
+
+import (
+	"context"
+	"log/slog"
+	"rest_api/internal/app/store"
+	"sync"
+	"time"
+)
+
+// worker runs a maintenance task at a fixed interval in the server process,
+// next to the HTTP server. An interval that is not positive disables it.
+type worker struct {
+	name string
+	interval time.Duration
+	task func(ctx context.Context, now time.Time) (int, error)
+	now func() time.Time
+
+	mu sync.Mutex
+	running bool
+	lastRun time.Time
+	lastErr error
+}
+
+// workerStatus is what the readiness check reports about a worker.
+type workerStatus struct {
+	Name string `json:"name"`
+	Running bool `json:"running"`
+	Disabled bool `json:"disabled"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+	LastRunFailed bool `json:"last_run_failed"`
+}
+
+// newPublisher publishes scheduled drafts once their publish_at has passed.
+func newPublisher(store store.Store, interval time.Duration) *worker {
+	return &worker{
+		name: "publisher",
+		interval: interval,
+		task: store.Article().PublishDue,
+		now: time.Now,
+	}
+}
+
+// newPurger permanently removes articles that have been in the trash for
+// longer than retention.
+func newPurger(store store.Store, interval time.Duration, retention time.Duration) *worker {
+	return &worker{
+		name: "purger",
+		interval: interval,
+		task: func(ctx context.Context, now time.Time) (int, error) {
+			return store.Article().PurgeTrash(ctx, now.Add(-retention))
+		},
+		now: time.Now,
+	}
+}
+
+// run executes the task every interval until stop is closed. A run that is
+// in progress when stop is closed gets its context cancelled. A disabled
+// worker returns at once.
+func (wk *worker) run(stop <-chan struct{}) {
+	if wk.disabled() {
+		return
+	}
+
+	ticker := time.NewTicker(wk.interval)
+	defer ticker.Stop()
+
+	wk.setRunning(true)
+	defer wk.setRunning(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := wk.runOnce(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("worker run failed", "worker", wk.name, "error", err)
+			}
+		}
+	}
+}
+
+func (wk *worker) runOnce(ctx context.Context) (int, error) {
+	now := wk.now().UTC()
+	n, err := wk.task(ctx, now)
+
+	wk.mu.Lock()
+	wk.lastRun = now
+	wk.lastErr = err
+	wk.mu.Unlock()
+
+	return n, err
+}
+
+// disabled reports whether the worker was configured not to run, with an
+// interval such as "0s".
+func (wk *worker) disabled() bool {
+	return wk.interval <= 0
+}
+
+func (wk *worker) setRunning(running bool) {
+	wk.mu.Lock()
+	wk.running = running
+	wk.mu.Unlock()
+}
+
+// status reports whether the worker is running and how its last run went.
+// The error itself is only logged, since it may come from the database.
+func (wk *worker) status() *workerStatus {
+	wk.mu.Lock()
+	defer wk.mu.Unlock()
+
+	st := &workerStatus{
+		Name: wk.name,
+		Running: wk.running,
+		Disabled: wk.disabled(),
+		LastRunFailed: wk.lastErr != nil,
+	}
+
+	if !wk.lastRun.IsZero() {
+		lastRun := wk.lastRun
+		st.LastRun = &lastRun
+	}
+
+	return st
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA implements the EdDSA (Ed25519) signing method from
+// RFC 8037, which jwt-go v3 does not ship with.
+var SigningMethodEdDSA = &signingMethodEd25519{}
+
+var errEd25519Verification = errors.New("ed25519: verification error")
+
+type signingMethodEd25519 struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *signingMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+func (m *signingMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(pub, []byte(signingString), sig) {
+		return errEd25519Verification
+	}
+
+	return nil
+}
+
+func (m *signingMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(priv, []byte(signingString))), nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK is the public part of a key as described in RFC 7517.
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyID string `json:"kid"`
+	Use string `json:"use"`
+	Algorithm string `json:"alg"`
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	Curve string `json:"crv,omitempty"`
+	X string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every verification key so that other services can check
+// tokens without sharing a secret with this one.
+func (km *KeyManager) JWKS() *JWKSet {
+	set := &JWKSet{Keys: make([]JWK, 0, len(km.order))}
+
+	for _, id := range km.order {
+		k := km.keys[id]
+		jwk := JWK{
+			KeyID: k.ID,
+			Use: "sig",
+			Algorithm: k.Method.Alg(),
+		}
+
+		switch pub := k.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
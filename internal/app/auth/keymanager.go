@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"io/fs"
+	"os"
+)
+
+var (
+	ErrNoSigningKey = errors.New("signing key is not configured")
+	ErrUnknownKey = errors.New("token signed with unknown key")
+	ErrUnexpectedAlgorithm = errors.New("unexpected signing algorithm")
+)
+
+// KeyConfig describes a single key in the apiserver config. A key without a
+// private key can only be used to verify tokens, which is how a retired
+// signing key is kept around until the tokens it issued have expired.
+type KeyConfig struct {
+	ID string `toml:"kid"`
+	Algorithm string `toml:"algorithm"`
+	PrivateKeyPath string `toml:"private_key_path"`
+	PublicKeyPath string `toml:"public_key_path"`
+}
+
+type Key struct {
+	ID string
+	Method jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey crypto.PublicKey
+}
+
+// KeyManager signs tokens with a single active key and verifies them with
+// any of the keys it knows about, selected by the "kid" header.
+type KeyManager struct {
+	signing *Key
+	keys map[string]*Key
+	order []string
+}
+
+func NewKeyManager(signing *Key, verification ...*Key) (*KeyManager, error) {
+	if signing == nil || signing.PrivateKey == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	km := &KeyManager{
+		signing: signing,
+		keys: make(map[string]*Key),
+	}
+
+	for _, k := range append([]*Key{signing}, verification...) {
+		if _, ok := km.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+
+		km.keys[k.ID] = k
+		km.order = append(km.order, k.ID)
+	}
+
+	return km, nil
+}
+
+// LoadKeyManager reads every configured key from its PEM files and uses the
+// key with signingKeyID to sign new tokens.
+func LoadKeyManager(signingKeyID string, configs []KeyConfig) (*KeyManager, error) {
+	var signing *Key
+	verification := make([]*Key, 0, len(configs))
+
+	for _, c := range configs {
+		k, err := loadKey(c)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", c.ID, err)
+		}
+
+		if k.ID == signingKeyID {
+			signing = k
+			continue
+		}
+
+		verification = append(verification, k)
+	}
+
+	return NewKeyManager(signing, verification...)
+}
+
+// Sign returns a signed token for the claims with the active key's "kid" in
+// the header.
+func (km *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(km.signing.Method, claims)
+	token.Header["kid"] = km.signing.ID
+
+	return token.SignedString(km.signing.PrivateKey)
+}
+
+// Keyfunc resolves the verification key for a parsed token. It is meant to be
+// passed to jwt.Parse and friends.
+func (km *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	k, ok := km.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != k.Method.Alg() {
+		return nil, ErrUnexpectedAlgorithm
+	}
+
+	return k.PublicKey, nil
+}
+
+func loadKey(c KeyConfig) (*Key, error) {
+	if c.ID == "" {
+		return nil, errors.New("kid is required")
+	}
+
+	k := &Key{ID: c.ID}
+
+	switch c.Algorithm {
+	case "RS256":
+		k.Method = jwt.SigningMethodRS256
+	case "EdDSA":
+		k.Method = SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", c.Algorithm)
+	}
+
+	if c.PrivateKeyPath != "" {
+		priv, err := readPEM(c.PrivateKeyPath, parsePrivateKey)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("private key %s does not exist, create it with: %s: %w", c.PrivateKeyPath, genpkeyCommand(c.Algorithm, c.PrivateKeyPath), err)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key cannot sign")
+		}
+
+		k.PrivateKey = priv
+		k.PublicKey = signer.Public()
+	}
+
+	if c.PublicKeyPath != "" {
+		pub, err := readPEM(c.PublicKeyPath, x509.ParsePKIXPublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		k.PublicKey = pub
+	}
+
+	if k.PublicKey == nil {
+		return nil, errors.New("private_key_path or public_key_path is required")
+	}
+
+	if err := checkKeyType(k); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// genpkeyCommand returns the openssl command that generates a private key
+// for the algorithm at path.
+func genpkeyCommand(algorithm string, path string) string {
+	if algorithm == "EdDSA" {
+		return "openssl genpkey -algorithm ed25519 -out " + path
+	}
+
+	return "openssl genpkey -algorithm RSA -pkeyopt rsa_keygen_bits:2048 -out " + path
+}
+
+func checkKeyType(k *Key) error {
+	switch k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if k.Method == jwt.SigningMethodRS256 {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if k.Method == SigningMethodEdDSA {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("key type %T does not match algorithm %s", k.PublicKey, k.Method.Alg())
+}
+
+func readPEM(path string, parse func([]byte) (interface{}, error)) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	return parse(block.Bytes)
+}
+
+func parsePrivateKey(der []byte) (interface{}, error) {
+	if k, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return k, nil
+	}
+
+	return x509.ParsePKCS1PrivateKey(der)
+}
@@ -0,0 +1,146 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"rest_api/internal/app/auth"
+	"testing"
+)
+
+func writeRSAKey(t *testing.T, dir string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "rsa.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func writeEd25519PublicKey(t *testing.T, dir string) (string, ed25519.PrivateKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "ed25519.pub.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, priv
+}
+
+func TestKeyManager_SignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	rsaPath := writeRSAKey(t, dir)
+	edPath, edPriv := writeEd25519PublicKey(t, dir)
+
+	km, err := auth.LoadKeyManager("new", []auth.KeyConfig{
+		{ID: "new", Algorithm: "RS256", PrivateKeyPath: rsaPath},
+		{ID: "old", Algorithm: "EdDSA", PublicKeyPath: edPath},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := km.Sign(&jwt.StandardClaims{Subject: "1"})
+	assert.NoError(t, err)
+
+	token, err := jwt.Parse(signed, km.Keyfunc)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", token.Header["kid"])
+	assert.Equal(t, "RS256", token.Method.Alg())
+
+	// A token issued by the retired key still verifies during rotation.
+	old := jwt.NewWithClaims(auth.SigningMethodEdDSA, &jwt.StandardClaims{Subject: "1"})
+	old.Header["kid"] = "old"
+	signed, err = old.SignedString(edPriv)
+	assert.NoError(t, err)
+
+	_, err = jwt.Parse(signed, km.Keyfunc)
+	assert.NoError(t, err)
+
+	old.Header["kid"] = "missing"
+	signed, _ = old.SignedString(edPriv)
+	_, err = jwt.Parse(signed, km.Keyfunc)
+	assert.Error(t, err)
+
+	old.Header["kid"] = "new"
+	signed, _ = old.SignedString(edPriv)
+	_, err = jwt.Parse(signed, km.Keyfunc)
+	assert.Error(t, err)
+}
+
+func TestKeyManager_JWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaPath := writeRSAKey(t, dir)
+	edPath, _ := writeEd25519PublicKey(t, dir)
+
+	km, err := auth.LoadKeyManager("new", []auth.KeyConfig{
+		{ID: "new", Algorithm: "RS256", PrivateKeyPath: rsaPath},
+		{ID: "old", Algorithm: "EdDSA", PublicKeyPath: edPath},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := km.JWKS()
+	assert.Len(t, set.Keys, 2)
+	assert.Equal(t, "RSA", set.Keys[0].KeyType)
+	assert.Equal(t, "new", set.Keys[0].KeyID)
+	assert.Equal(t, "OKP", set.Keys[1].KeyType)
+	assert.Equal(t, "Ed25519", set.Keys[1].Curve)
+}
+
+func TestLoadKeyManager_Errors(t *testing.T) {
+	dir := t.TempDir()
+	edPath, _ := writeEd25519PublicKey(t, dir)
+
+	_, err := auth.LoadKeyManager("old", []auth.KeyConfig{
+		{ID: "old", Algorithm: "EdDSA", PublicKeyPath: edPath},
+	})
+	assert.Equal(t, auth.ErrNoSigningKey, err)
+
+	_, err = auth.LoadKeyManager("old", []auth.KeyConfig{
+		{ID: "old", Algorithm: "RS256", PublicKeyPath: edPath},
+	})
+	assert.Error(t, err)
+
+	_, err = auth.LoadKeyManager("", nil)
+	assert.Equal(t, auth.ErrNoSigningKey, err)
+
+	missing := filepath.Join(dir, "missing.pem")
+	_, err = auth.LoadKeyManager("new", []auth.KeyConfig{
+		{ID: "new", Algorithm: "RS256", PrivateKeyPath: missing},
+	})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Contains(t, err.Error(), missing)
+	assert.Contains(t, err.Error(), "openssl genpkey -algorithm RSA -pkeyopt rsa_keygen_bits:2048 -out " + missing)
+}
@@ -0,0 +1,106 @@
+package auth
+
+import "rest_api/internal/app/model"
+
+type Action string
+
+const (
+	ActionCreateArticle Action = "create_article"
+	ActionChangeArticle Action = "change_article"
+	ActionDeleteArticle Action = "delete_article"
+)
+
+// Principal is the authenticated caller as described by an access token.
+type Principal struct {
+	UserID int
+	Role string
+}
+
+// Can reports whether the principal may perform the action on the article.
+// Authors manage their own articles, editors may change anyone's article
+// that is not private but only delete their own, and admins may do anything.
+// Readers may only read.
+func (p *Principal) Can(action Action, a *model.Article) bool {
+	if p == nil {
+		return false
+	}
+
+	switch p.Role {
+	case model.RoleAdmin:
+		return true
+	case model.RoleEditor:
+		if action == ActionChangeArticle && (a == nil || a.Visibility != model.VisibilityPrivate) {
+			return true
+		}
+
+		return action == ActionCreateArticle || p.owns(a)
+	case model.RoleAuthor:
+		return action == ActionCreateArticle || p.owns(a)
+	}
+
+	return false
+}
+
+// CanRead reports whether the principal may read the article. Published
+// articles that are not private are open to everyone, including anonymous
+// callers whose principal is nil. Private articles are personal notes and
+// only their author reads them. Everything else is limited to the author and
+// to the roles that may change any article.
+func (p *Principal) CanRead(a *model.Article) bool {
+	if a == nil {
+		return false
+	}
+
+	if a.IsReadable() {
+		return true
+	}
+
+	if p != nil && p.owns(a) {
+		return true
+	}
+
+	return p.SeesAllArticles() && a.Visibility != model.VisibilityPrivate
+}
+
+// SeesAllArticles reports whether listings should include unpublished
+// articles of other users for the principal. Private articles of other users
+// are left out all the same.
+func (p *Principal) SeesAllArticles() bool {
+	return p != nil && (p.Role == model.RoleAdmin || p.Role == model.RoleEditor)
+}
+
+// Audience returns the listing restriction that matches the principal.
+func (p *Principal) Audience() model.Audience {
+	if p == nil {
+		return model.Audience{}
+	}
+
+	return model.Audience{
+		ViewerID: p.UserID,
+		All: p.SeesAllArticles(),
+	}
+}
+
+func (p *Principal) owns(a *model.Article) bool {
+	return a != nil && a.AuthorID == p.UserID
+}
+
+// CanManageNotebook reports whether the principal may read or change the
+// notebook. Notebooks are personal, so only the owner and admins qualify.
+func (p *Principal) CanManageNotebook(n *model.Notebook) bool {
+	if p == nil || n == nil {
+		return false
+	}
+
+	return p.Role == model.RoleAdmin || n.OwnerID == p.UserID
+}
+
+// CanManageTag reports whether the principal may rename or merge the tag.
+// Like notebooks, tags belong to a single user.
+func (p *Principal) CanManageTag(t *model.Tag) bool {
+	if p == nil || t == nil {
+		return false
+	}
+
+	return p.Role == model.RoleAdmin || t.OwnerID == p.UserID
+}
@@ -0,0 +1,85 @@
+package auth_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/auth"
+	"rest_api/internal/app/model"
+	"testing"
+)
+
+func TestPrincipal_Can(t *testing.T) {
+	own := &model.Article{AuthorID: 1}
+	other := &model.Article{AuthorID: 2}
+	otherPrivate := &model.Article{AuthorID: 2, Visibility: model.VisibilityPrivate}
+	ownPrivate := &model.Article{AuthorID: 1, Visibility: model.VisibilityPrivate}
+
+	testCases := []struct {
+		name string
+		role string
+		action auth.Action
+		article *model.Article
+		expected bool
+	}{
+		{"reader creates", model.RoleReader, auth.ActionCreateArticle, nil, false},
+		{"reader changes own", model.RoleReader, auth.ActionChangeArticle, own, false},
+		{"author creates", model.RoleAuthor, auth.ActionCreateArticle, nil, true},
+		{"author changes own", model.RoleAuthor, auth.ActionChangeArticle, own, true},
+		{"author changes other", model.RoleAuthor, auth.ActionChangeArticle, other, false},
+		{"author deletes other", model.RoleAuthor, auth.ActionDeleteArticle, other, false},
+		{"editor changes other", model.RoleEditor, auth.ActionChangeArticle, other, true},
+		{"editor changes other private", model.RoleEditor, auth.ActionChangeArticle, otherPrivate, false},
+		{"editor changes own private", model.RoleEditor, auth.ActionChangeArticle, ownPrivate, true},
+		{"editor deletes other", model.RoleEditor, auth.ActionDeleteArticle, other, false},
+		{"editor deletes own", model.RoleEditor, auth.ActionDeleteArticle, own, true},
+		{"admin deletes other", model.RoleAdmin, auth.ActionDeleteArticle, other, true},
+		{"unknown role", "guest", auth.ActionCreateArticle, nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &auth.Principal{UserID: 1, Role: tc.role}
+			assert.Equal(t, tc.expected, p.Can(tc.action, tc.article))
+		})
+	}
+}
+
+func TestPrincipal_CanRead(t *testing.T) {
+	public := &model.Article{AuthorID: 2, Status: model.StatusPublished, Visibility: model.VisibilityPublic}
+	unlisted := &model.Article{AuthorID: 2, Status: model.StatusPublished, Visibility: model.VisibilityUnlisted}
+	private := &model.Article{AuthorID: 2, Status: model.StatusPublished, Visibility: model.VisibilityPrivate}
+	draft := &model.Article{AuthorID: 2, Status: model.StatusDraft, Visibility: model.VisibilityPublic}
+
+	author := &auth.Principal{UserID: 2, Role: model.RoleAuthor}
+	reader := &auth.Principal{UserID: 1, Role: model.RoleReader}
+	editor := &auth.Principal{UserID: 1, Role: model.RoleEditor}
+	admin := &auth.Principal{UserID: 3, Role: model.RoleAdmin}
+	privateDraft := &model.Article{AuthorID: 2, Status: model.StatusDraft, Visibility: model.VisibilityPrivate}
+
+	testCases := []struct {
+		name string
+		principal *auth.Principal
+		article *model.Article
+		expected bool
+	}{
+		{"anonymous public", nil, public, true},
+		{"anonymous unlisted", nil, unlisted, true},
+		{"anonymous private", nil, private, false},
+		{"anonymous draft", nil, draft, false},
+		{"reader draft", reader, draft, false},
+		{"author own draft", author, draft, true},
+		{"author own private", author, private, true},
+		{"editor draft", editor, draft, true},
+		{"reader private", reader, private, false},
+		{"editor private", editor, private, false},
+		{"editor private draft", editor, privateDraft, false},
+		{"admin private", admin, private, false},
+		{"admin draft", admin, draft, true},
+		{"author own private draft", author, privateDraft, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.principal.CanRead(tc.article))
+		})
+	}
+}
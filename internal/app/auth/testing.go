@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+// TestKeyManager returns a key manager with a freshly generated Ed25519
+// signing key.
+func TestKeyManager(t *testing.T) *KeyManager {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	km, err := NewKeyManager(&Key{
+		ID: "test",
+		Method: SigningMethodEdDSA,
+		PrivateKey: priv,
+		PublicKey: pub,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return km
+}
@@ -0,0 +1,58 @@
+// Package logging builds the structured logger of the server and carries a
+// request-scoped logger in the context, so that handlers and repositories
+// log with the request's ID attached.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+const (
+	FormatJSON Format = "json"
+	FormatText Format = "text"
+)
+
+// Format selects the slog handler: one JSON object per line, or key=value
+// text for reading logs in a terminal.
+type Format string
+
+func (f *Format) UnmarshalText(text []byte) error {
+	switch v := Format(text); v {
+	case FormatJSON, FormatText:
+		*f = v
+		return nil
+	}
+
+	return fmt.Errorf("unknown log format %q, want json or text", text)
+}
+
+// New returns a logger that writes records of level and above to w.
+func New(w io.Writer, level slog.Level, format Format) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	if format == FormatText {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx that carries logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+// outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
@@ -0,0 +1,43 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"rest_api/internal/app/logging"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := logging.New(out, slog.LevelWarn, logging.FormatJSON)
+
+	logger.Info("dropped")
+	logger.Warn("kept", "user_id", 1)
+
+	record := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "kept", record["msg"])
+	assert.Equal(t, 1.0, record["user_id"])
+
+	out.Reset()
+	logging.New(out, slog.LevelInfo, logging.FormatText).Info("text")
+	assert.Contains(t, out.String(), "msg=text")
+}
+
+func TestFormat_UnmarshalText(t *testing.T) {
+	var f logging.Format
+	assert.NoError(t, f.UnmarshalText([]byte("text")))
+	assert.Equal(t, logging.FormatText, f)
+	assert.Error(t, f.UnmarshalText([]byte("xml")))
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), logging.FromContext(context.Background()))
+
+	logger := logging.New(&bytes.Buffer{}, slog.LevelInfo, logging.FormatJSON)
+	ctx := logging.NewContext(context.Background(), logger)
+	assert.Equal(t, logger, logging.FromContext(ctx))
+}
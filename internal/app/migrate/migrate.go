@@ -0,0 +1,264 @@
+// Package migrate applies the schema migrations kept in golang-migrate's
+// file layout: <version>_<name>.up.sql and <version>_<name>.down.sql. The
+// current version is recorded in schema_migrations the way golang-migrate
+// records it, so databases migrated by hand keep working.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoChange = errors.New("no change")
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+type Migration struct {
+	Version int64
+	Name string
+	Up string
+	Down string
+}
+
+// Status tells whether a migration has been applied.
+type Status struct {
+	*Migration
+	Applied bool
+}
+
+type Migrator struct {
+	db *sql.DB
+	migrations []*Migration
+}
+
+// New reads the migrations in fsys. Every version needs both an up and a down
+// file.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		name := path.Base(file)
+
+		direction := ""
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: not an up or down file", name)
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(name, "."+direction+".sql"), "_", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s: name is not <version>_<name>", name)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	mg := &Migrator{db: db}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d: missing or empty up or down file", m.Version)
+		}
+
+		mg.migrations = append(mg.migrations, m)
+	}
+
+	sort.Slice(mg.migrations, func(i, j int) bool {
+		return mg.migrations[i].Version < mg.migrations[j].Version
+	})
+
+	return mg, nil
+}
+
+// Version returns the version the database is at, 0 for an empty one. Dirty
+// is set when golang-migrate failed halfway through a migration.
+func (mg *Migrator) Version() (version int64, dirty bool, err error) {
+	if _, err := mg.db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version bigint not null primary key, dirty boolean not null)"); err != nil {
+		return 0, false, err
+	}
+
+	if err := mg.db.QueryRow("SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty); err != nil && err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+// CurrentVersion is Version for health checks: it gives up with ctx and
+// reads schema_migrations without creating it, so a database that was never
+// migrated is reported with an error.
+func (mg *Migrator) CurrentVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	if err := mg.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty); err != nil && err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+// Latest returns the version of the newest migration.
+func (mg *Migrator) Latest() int64 {
+	if len(mg.migrations) == 0 {
+		return 0
+	}
+
+	return mg.migrations[len(mg.migrations) - 1].Version
+}
+
+// Status lists every migration, oldest first.
+func (mg *Migrator) Status() ([]*Status, error) {
+	version, _, err := mg.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]*Status, 0, len(mg.migrations))
+	for _, m := range mg.migrations {
+		ss = append(ss, &Status{Migration: m, Applied: m.Version <= version})
+	}
+
+	return ss, nil
+}
+
+// Up applies all pending migrations.
+func (mg *Migrator) Up() error {
+	return mg.Goto(mg.Latest())
+}
+
+// Down rolls back the most recently applied migration.
+func (mg *Migrator) Down() error {
+	version, _, err := mg.Version()
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		return ErrNoChange
+	}
+
+	target := int64(0)
+	for _, m := range mg.migrations {
+		if m.Version < version {
+			target = m.Version
+		}
+	}
+
+	return mg.Goto(target)
+}
+
+// Goto migrates up or down until the database is at the given version. 0
+// rolls back every migration.
+func (mg *Migrator) Goto(target int64) error {
+	if target != 0 && mg.find(target) == nil {
+		return ErrUnknownVersion
+	}
+
+	version, dirty, err := mg.Version()
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d, fix it and force the version by hand", version)
+	}
+
+	if version == target {
+		return ErrNoChange
+	}
+
+	if version < target {
+		for _, m := range mg.migrations {
+			if m.Version > version && m.Version <= target {
+				if err := mg.apply(m, m.Up, m.Version); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for i := len(mg.migrations) - 1; i >= 0; i-- {
+		m := mg.migrations[i]
+		if m.Version > version || m.Version <= target {
+			continue
+		}
+
+		previous := int64(0)
+		if i > 0 {
+			previous = mg.migrations[i - 1].Version
+		}
+
+		if err := mg.apply(m, m.Down, previous); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (mg *Migrator) find(version int64) *Migration {
+	for _, m := range mg.migrations {
+		if m.Version == version {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// apply runs one migration file and records the resulting version in the
+// same transaction, so a failing migration leaves nothing behind.
+func (mg *Migrator) apply(m *Migration, body string, version int64) error {
+	tx, err := mg.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(body); err != nil {
+		return fmt.Errorf("migration %d_%s: %v", m.Version, m.Name, err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+
+	if version != 0 {
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,96 @@
+package migrate_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"rest_api/internal/app/migrate"
+	"rest_api/internal/app/store/sqlitestore"
+	"rest_api/migrations"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrator(t *testing.T) {
+	db, err := sqlitestore.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, fstest.MapFS{
+		"1_create_a.up.sql": {Data: []byte("CREATE TABLE a (id integer);")},
+		"1_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"2_create_b.up.sql": {Data: []byte("CREATE TABLE b (id integer);")},
+		"2_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+		"3_broken.up.sql": {Data: []byte("CREATE TABLE c (id integer); NOT SQL;")},
+		"3_broken.down.sql": {Data: []byte("DROP TABLE c;")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), m.Latest())
+
+	_, _, err = m.CurrentVersion(context.Background())
+	assert.Error(t, err)
+
+	assert.NoError(t, m.Goto(2))
+	assert.Equal(t, migrate.ErrNoChange, m.Goto(2))
+	assert.Equal(t, migrate.ErrUnknownVersion, m.Goto(4))
+
+	// A failing migration is rolled back together with its version.
+	assert.Error(t, m.Up())
+	version, dirty, err := m.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.False(t, dirty)
+
+	version, dirty, err = m.CurrentVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.False(t, dirty)
+
+	_, err = db.Exec("SELECT * FROM c")
+	assert.Error(t, err)
+
+	ss, err := m.Status()
+	assert.NoError(t, err)
+	assert.Len(t, ss, 3)
+	assert.True(t, ss[1].Applied)
+	assert.False(t, ss[2].Applied)
+
+	assert.NoError(t, m.Down())
+	version, _, _ = m.Version()
+	assert.Equal(t, int64(1), version)
+
+	_, err = db.Exec("SELECT * FROM b")
+	assert.Error(t, err)
+
+	assert.NoError(t, m.Goto(0))
+	assert.Equal(t, migrate.ErrNoChange, m.Down())
+}
+
+func TestMigrator_MissingDown(t *testing.T) {
+	_, err := migrate.New(nil, fstest.MapFS{
+		"1_create_a.up.sql": {Data: []byte("CREATE TABLE a (id integer);")},
+	})
+	assert.Error(t, err)
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	db, err := sqlitestore.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, migrations.SQLite())
+	assert.NoError(t, err)
+	assert.NoError(t, m.Up())
+	assert.NoError(t, m.Goto(0))
+	assert.NoError(t, m.Up())
+}
+
+func TestMigrator_Postgres(t *testing.T) {
+	m, err := migrate.New(nil, migrations.Postgres())
+	assert.NoError(t, err)
+	assert.NotZero(t, m.Latest())
+}
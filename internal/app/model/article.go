@@ -1,5 +1,17 @@
 package model
 
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+// Limits of the article columns. Headings are stored as varchar(50), the
+// text limit only keeps single articles at a sensible size.
+const (
+	MaxHeadingLength = 50
+	MaxTextLength = 100000
+)
+
 type Article struct {
 	ID int `json:"id"`
 	Heading string `json:"article_heading"`
@@ -7,4 +19,24 @@ type Article struct {
 	Date string `json:"creating_date"`
 	AuthorID int `json:"author_id,omitempty"`
 	AuthorName string `json:"author_name,omitempty"`
-}
\ No newline at end of file
+	NotebookID int `json:"notebook_id,omitempty"`
+	Version int `json:"version"`
+	Tags []string `json:"tags,omitempty"`
+	Status string `json:"status"`
+	Visibility string `json:"visibility"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Validate checks the content of the article. Status and visibility may be
+// left empty, BeforeCreate fills them in.
+func (a *Article) Validate() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.Heading, validation.Required, validation.RuneLength(1, MaxHeadingLength), validation.By(notBlank), validation.By(singleLine)),
+		validation.Field(&a.Text, validation.Required, validation.RuneLength(1, MaxTextLength), validation.By(notBlank)),
+		validation.Field(&a.Status, validation.In(StatusDraft, StatusPublished, StatusArchived)),
+		validation.Field(&a.Visibility, validation.In(VisibilityPublic, VisibilityUnlisted, VisibilityPrivate)),
+	)
+}
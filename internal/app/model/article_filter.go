@@ -0,0 +1,137 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	SortCreated = "created"
+	SortHeading = "heading"
+	// DefaultArticleSort lists the newest articles first.
+	DefaultArticleSort = "-" + SortCreated
+
+	DefaultPageSize = 20
+	MaxPageSize = 100
+)
+
+var (
+	ErrInvalidSort = errors.New("sort must be one of created, -created, heading, -heading")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidTagMode = errors.New("tag_mode must be either all or any")
+)
+
+// ArticleFilter selects a page of articles. Zero values mean "no
+// restriction"; Sort is a sort key optionally prefixed with "-" for
+// descending order. With TagModeAny an article needs one of Tags, otherwise
+// it needs all of them. Audience decides which unpublished articles are
+// included.
+type ArticleFilter struct {
+	Audience
+	AuthorID int
+	NotebookID int
+	From time.Time
+	To time.Time
+	Tags []string
+	TagMode string
+	Status string
+	Sort string
+	Cursor *ArticleCursor
+	Limit int
+}
+
+// ArticleCursor marks the last article of a page. The next page starts right
+// after it in the order given by Sort, which keeps paging stable while
+// articles are added or removed.
+type ArticleCursor struct {
+	Sort string `json:"s"`
+	Key string `json:"k"`
+	ID int `json:"id"`
+}
+
+// SortKey splits the filter's sort into the key and its direction.
+func (f *ArticleFilter) SortKey() (string, bool, error) {
+	sort := f.Sort
+	if sort == "" {
+		sort = DefaultArticleSort
+	}
+
+	key := strings.TrimPrefix(sort, "-")
+	if key != SortCreated && key != SortHeading {
+		return "", false, ErrInvalidSort
+	}
+
+	return key, strings.HasPrefix(sort, "-"), nil
+}
+
+// PageSize returns Limit clamped to [1, MaxPageSize], or DefaultPageSize when
+// no limit was given.
+func (f *ArticleFilter) PageSize() int {
+	switch {
+	case f.Limit < 1:
+		return DefaultPageSize
+	case f.Limit > MaxPageSize:
+		return MaxPageSize
+	}
+
+	return f.Limit
+}
+
+// NewArticleCursor returns the cursor pointing after a for the given sort.
+func NewArticleCursor(sort string, a *Article) *ArticleCursor {
+	if sort == "" {
+		sort = DefaultArticleSort
+	}
+
+	c := &ArticleCursor{
+		Sort: sort,
+		ID: a.ID,
+	}
+
+	if strings.TrimPrefix(sort, "-") == SortHeading {
+		c.Key = a.Heading
+	} else {
+		c.Key = a.Date
+	}
+
+	return c
+}
+
+func (c *ArticleCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeArticleCursor parses an opaque cursor and checks that it was issued
+// for the same sort order and that its key fits that order, so that stores
+// can bind the key without checking it again.
+func DecodeArticleCursor(s string, sort string) (*ArticleCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &ArticleCursor{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if sort == "" {
+		sort = DefaultArticleSort
+	}
+
+	if c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+
+	if strings.TrimPrefix(sort, "-") == SortCreated {
+		if _, err := time.Parse(time.RFC3339, c.Key); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return c, nil
+}
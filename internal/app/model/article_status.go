@@ -0,0 +1,123 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	StatusDraft = "draft"
+	StatusPublished = "published"
+	StatusArchived = "archived"
+)
+
+// Unlisted articles can be read by anyone who knows them, but they are left
+// out of listings and search. Private articles are only visible to their
+// author.
+const (
+	VisibilityPublic = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate = "private"
+)
+
+var (
+	ErrInvalidTransition = errors.New("article cannot make this status transition")
+	ErrInvalidVisibility = errors.New("visibility must be one of public, unlisted, private")
+	ErrInvalidStatus = errors.New("status must be one of draft, published, archived")
+)
+
+// Audience restricts a listing to what a reader may see: published public
+// articles, plus every article of ViewerID. All lifts the restriction, except
+// that private articles are only ever listed for their author.
+type Audience struct {
+	ViewerID int
+	All bool
+}
+
+// BeforeCreate fills in the lifecycle defaults of a new article.
+func (a *Article) BeforeCreate() {
+	if a.Status == "" {
+		a.Status = StatusDraft
+	}
+
+	if a.Visibility == "" {
+		a.Visibility = VisibilityPublic
+	}
+
+	if a.Status == StatusPublished && a.PublishedAt == nil {
+		now := time.Now().UTC()
+		a.PublishedAt = &now
+	}
+}
+
+// IsListed reports whether the article shows up in public listings.
+func (a *Article) IsListed() bool {
+	return a.Status == StatusPublished && a.Visibility == VisibilityPublic
+}
+
+// IsReadable reports whether anybody may read the article by its id or
+// heading.
+func (a *Article) IsReadable() bool {
+	return a.Status == StatusPublished && a.Visibility != VisibilityPrivate
+}
+
+// Publish makes a draft or archived article visible. A time in the future
+// schedules a draft to be published then instead.
+func (a *Article) Publish(at time.Time, now time.Time) error {
+	switch {
+	case a.Status == StatusDraft:
+	case a.Status == StatusArchived && !at.After(now):
+	default:
+		return ErrInvalidTransition
+	}
+
+	if at.After(now) {
+		a.PublishAt = &at
+		return nil
+	}
+
+	a.Status = StatusPublished
+	a.PublishedAt = &now
+	a.PublishAt = nil
+
+	return nil
+}
+
+// Unpublish turns a published article back into a draft. For a scheduled
+// draft it cancels the schedule.
+func (a *Article) Unpublish() error {
+	if a.Status != StatusPublished && (a.Status != StatusDraft || a.PublishAt == nil) {
+		return ErrInvalidTransition
+	}
+
+	a.Status = StatusDraft
+	a.PublishAt = nil
+
+	return nil
+}
+
+func (a *Article) Archive() error {
+	if a.Status == StatusArchived {
+		return ErrInvalidTransition
+	}
+
+	a.Status = StatusArchived
+	a.PublishAt = nil
+
+	return nil
+}
+
+func (a *Article) SetVisibility(v string) error {
+	switch v {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		a.Visibility = v
+		return nil
+	}
+
+	return ErrInvalidVisibility
+}
+
+// ValidStatus reports whether s is one of the article statuses.
+func ValidStatus(s string) bool {
+	return s == StatusDraft || s == StatusPublished || s == StatusArchived
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+// DefaultNotebookName is used for the notebook that is created on demand for
+// articles which are not put into a notebook explicitly.
+const DefaultNotebookName = "Notes"
+
+type Notebook struct {
+	ID int `json:"id"`
+	Name string `json:"name"`
+	OwnerID int `json:"owner_id"`
+	IsDefault bool `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (n *Notebook) Validate() error {
+	return validation.ValidateStruct(
+		n,
+		validation.Field(&n.Name, validation.Required, validation.Length(1, 50)),
+	)
+}
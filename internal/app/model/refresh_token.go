@@ -0,0 +1,72 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshToken is the server-side record of an issued refresh token. Only the
+// hash of the token is stored; every token rotated from the same login shares
+// a FamilyID so that the whole chain can be revoked at once.
+type RefreshToken struct {
+	ID int `json:"-"`
+	UserID int `json:"user_id"`
+	TokenHash string `json:"-"`
+	FamilyID string `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"-"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// NewRefreshToken generates a random refresh token for the user. The plain
+// token is returned separately and is never kept on the record. An empty
+// familyID starts a new family.
+func NewRefreshToken(userID int, familyID string, ttl time.Duration) (*RefreshToken, string, error) {
+	raw, err := randomBytes(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if familyID == "" {
+		f, err := randomBytes(16)
+		if err != nil {
+			return nil, "", err
+		}
+
+		familyID = hex.EncodeToString(f)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	return &RefreshToken{
+		UserID: userID,
+		TokenHash: HashRefreshToken(token),
+		FamilyID: familyID,
+		ExpiresAt: time.Now().Add(ttl),
+	}, token, nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *RefreshToken) IsExpired() bool {
+	return !time.Now().Before(t.ExpiresAt)
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
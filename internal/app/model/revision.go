@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Revision is an immutable snapshot of an article taken every time its
+// content is written. Number counts up from 1 for each article.
+type Revision struct {
+	ID int `json:"-"`
+	ArticleID int `json:"article_id"`
+	Number int `json:"revision"`
+	Heading string `json:"article_heading"`
+	Text string `json:"article_text"`
+	AuthorID int `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewRevision snapshots the current content of the article on behalf of the
+// user who wrote it.
+func NewRevision(a *Article, authorID int) *Revision {
+	return &Revision{
+		ArticleID: a.ID,
+		Heading: a.Heading,
+		Text: a.Text,
+		AuthorID: authorID,
+	}
+}
@@ -0,0 +1,13 @@
+package model
+
+const (
+	RoleReader = "reader"
+	RoleAuthor = "author"
+	RoleEditor = "editor"
+	RoleAdmin = "admin"
+)
+
+// DefaultRole is given to users who sign up through the public API.
+const DefaultRole = RoleAuthor
+
+var roles = []interface{}{RoleReader, RoleAuthor, RoleEditor, RoleAdmin}
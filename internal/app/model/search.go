@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// SearchFilter narrows a full-text search. Zero values mean "no restriction".
+type SearchFilter struct {
+	Audience
+	Query string
+	AuthorID int
+	From time.Time
+	To time.Time
+	Limit int
+}
+
+// SearchResult is a matching article with its relevance and the matched
+// words wrapped in <mark> tags.
+type SearchResult struct {
+	*Article
+	Rank float64 `json:"rank"`
+	HeadingHighlight string `json:"heading_highlight"`
+	Snippet string `json:"snippet"`
+}
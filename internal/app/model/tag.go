@@ -0,0 +1,53 @@
+package model
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"sort"
+	"strings"
+)
+
+const (
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+// Tag labels articles of a single owner, so tags with the same name belonging
+// to different users are unrelated.
+type Tag struct {
+	ID int `json:"id"`
+	Name string `json:"name"`
+	OwnerID int `json:"owner_id"`
+	ArticleCount int `json:"article_count"`
+}
+
+func (t *Tag) Validate() error {
+	return validation.ValidateStruct(
+		t,
+		validation.Field(&t.Name, validation.Required, validation.Length(1, 50)),
+	)
+}
+
+// NormalizeTags trims and lower-cases tag names, validates them and returns
+// them sorted without duplicates.
+func NormalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(names))
+
+	for _, name := range names {
+		t := &Tag{Name: strings.ToLower(strings.TrimSpace(name))}
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+
+		if seen[t.Name] {
+			continue
+		}
+
+		seen[t.Name] = true
+		tags = append(tags, t.Name)
+	}
+
+	sort.Strings(tags)
+
+	return tags, nil
+}
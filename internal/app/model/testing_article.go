@@ -9,5 +9,6 @@ func TestArticle(t *testing.T, id int) *Article {
 		Heading: "TestArticle",
 		Text: "TestArticle",
 		AuthorID: id,
+		Status: StatusPublished,
 	}
 }
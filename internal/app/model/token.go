@@ -8,4 +8,5 @@ import(
 type Token struct {
 	jwt.StandardClaims
 	ID int `json:"id"`
+	Role string `json:"role"`
 }
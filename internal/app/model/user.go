@@ -12,6 +12,7 @@ type User struct {
 	Email string `json:"email"`
 	Password string `json:"password,omitempty"`
 	EncryptedPassword string `json:"-"`
+	Role string `json:"role"`
 }
 
 func (u *User) Validate() error {
@@ -20,10 +21,15 @@ func (u *User) Validate() error {
 		validation.Field(&u.Name, validation.Required, validation.Length(3, 15)),
 		validation.Field(&u.Email, validation.Required, is.Email),
 		validation.Field(&u.Password, validation.By(requiredIf(u.EncryptedPassword == "")), validation.Length(6, 50)),
+		validation.Field(&u.Role, validation.In(roles...)),
 	)
 }
 
 func (u *User) BeforeCreate() error {
+	if u.Role == "" {
+		u.Role = DefaultRole
+	}
+
 	if u.Password != "" {
 		enc, err := encryptString(u.Password)
 		if err != nil {
@@ -1,6 +1,11 @@
 package model
 
-import validation "github.com/go-ozzo/ozzo-validation"
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"strings"
+	"unicode"
+)
 
 func requiredIf(cond bool) validation.RuleFunc {
 	return func(data interface{}) error {
@@ -10,4 +15,25 @@ func requiredIf(cond bool) validation.RuleFunc {
 
 		return nil
 	}
-}
\ No newline at end of file
+}
+
+// notBlank rejects strings made of white space only, which Required lets
+// through.
+func notBlank(data interface{}) error {
+	s, _ := data.(string)
+	if s != "" && strings.TrimSpace(s) == "" {
+		return errors.New("must not be blank")
+	}
+
+	return nil
+}
+
+// singleLine rejects line breaks and other control characters.
+func singleLine(data interface{}) error {
+	s, _ := data.(string)
+	if strings.IndexFunc(s, unicode.IsControl) >= 0 {
+		return errors.New("must be a single line without control characters")
+	}
+
+	return nil
+}
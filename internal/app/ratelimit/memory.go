@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory drops the buckets that have filled up
+// again, which are no different from buckets that do not exist.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	updated time.Time
+	full time.Time
+}
+
+// Memory is a Limiter that keeps the buckets of one process.
+type Memory struct {
+	mu sync.Mutex
+	buckets map[string]*bucket
+	swept time.Time
+	now func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory {
+		buckets: make(map[string]*bucket),
+		now: time.Now,
+	}
+}
+
+func (m *Memory) Allow(ctx context.Context, key string, p Policy) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !p.Enabled() {
+		return &Result{Allowed: true, Limit: p.Burst, Remaining: p.Burst}, nil
+	}
+
+	now := m.now()
+	interval := p.interval()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(p.Burst), updated: now}
+		m.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(interval)
+		b.updated = now
+	}
+
+	if b.tokens > float64(p.Burst) {
+		b.tokens = float64(p.Burst)
+	}
+
+	res := &Result{Limit: p.Burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((float64(p.Burst) - b.tokens) * float64(interval))
+	b.full = now.Add(res.Reset)
+
+	return res, nil
+}
+
+// sweep drops the buckets that are full by now. The caller holds the lock.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepInterval {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if !b.full.After(now) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.swept = now
+}
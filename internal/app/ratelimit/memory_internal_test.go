@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func testMemory(now *time.Time) *Memory {
+	m := NewMemory()
+	m.now = func() time.Time {
+		return *now
+	}
+
+	return m
+}
+
+func TestMemory_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := testMemory(&now)
+	p := Policy{Rate: 1, Period: 10 * time.Second, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		res, err := m.Allow(context.Background(), "ip:1", p)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, 2 - i, res.Remaining)
+		assert.Equal(t, time.Duration(i + 1) * 10 * time.Second, res.Reset)
+	}
+
+	res, err := m.Allow(context.Background(), "ip:1", p)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, 10 * time.Second, res.RetryAfter)
+
+	// Other keys have buckets of their own.
+	res, _ = m.Allow(context.Background(), "ip:2", p)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(4 * time.Second)
+	res, _ = m.Allow(context.Background(), "ip:1", p)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 6 * time.Second, res.RetryAfter)
+
+	now = now.Add(6 * time.Second)
+	res, _ = m.Allow(context.Background(), "ip:1", p)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// A bucket never holds more than Burst tokens.
+	now = now.Add(time.Hour)
+	res, _ = m.Allow(context.Background(), "ip:1", p)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining)
+}
+
+func TestMemory_AllowDisabled(t *testing.T) {
+	m := NewMemory()
+
+	for i := 0; i < 10; i++ {
+		res, err := m.Allow(context.Background(), "ip:1", Policy{})
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	assert.Empty(t, m.buckets)
+}
+
+func TestMemory_AllowCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewMemory().Allow(ctx, "ip:1", Policy{Rate: 1, Period: time.Second, Burst: 1})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMemory_Sweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := testMemory(&now)
+	p := Policy{Rate: 1, Period: time.Second, Burst: 5}
+
+	m.Allow(context.Background(), "ip:1", p)
+	now = now.Add(time.Minute)
+	m.Allow(context.Background(), "ip:2", p)
+
+	assert.NotContains(t, m.buckets, "ip:1")
+	assert.Contains(t, m.buckets, "ip:2")
+}
@@ -0,0 +1,46 @@
+// Package ratelimit counts requests against token bucket policies. The
+// buckets live in a Limiter, so that servers behind a load balancer can share
+// them by plugging in a backend other than the in-memory one.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Policy is a token bucket that holds up to Burst tokens and is refilled with
+// Rate tokens every Period. Each request takes one token.
+type Policy struct {
+	Rate int
+	Period time.Duration
+	Burst int
+}
+
+// Enabled reports whether the policy limits anything. A policy without a rate
+// or period lets every request through.
+func (p Policy) Enabled() bool {
+	return p.Rate > 0 && p.Period > 0 && p.Burst > 0
+}
+
+// interval is the time it takes to refill one token.
+func (p Policy) interval() time.Duration {
+	return p.Period / time.Duration(p.Rate)
+}
+
+// Result is the state of a bucket after a request was counted. Reset is the
+// time until the bucket is full again and RetryAfter, for a request that was
+// refused, the time until the next token is there.
+type Result struct {
+	Allowed bool
+	Limit int
+	Remaining int
+	Reset time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter keeps the buckets. Allow takes a token from the bucket of key,
+// which is created full under policy p when it does not exist yet. Requests
+// are refused, not queued, once the bucket is empty.
+type Limiter interface {
+	Allow(ctx context.Context, key string, p Policy) (*Result, error)
+}
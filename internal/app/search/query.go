@@ -0,0 +1,98 @@
+// Package search parses user search queries and provides the in-memory
+// matching and ranking used where PostgreSQL full-text search is not
+// available.
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrEmptyQuery = errors.New("search query has no words")
+
+// Term is a single word or a quoted phrase. Prefix applies to the last word
+// and is requested with a trailing "*".
+type Term struct {
+	Words []string
+	Prefix bool
+}
+
+type Query struct {
+	Terms []Term
+}
+
+// Parse understands plain words, "quoted phrases" and prefix* words. Every
+// term has to match for a document to be found.
+func Parse(q string) (*Query, error) {
+	query := &Query{}
+	rest := q
+
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		var raw string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				raw, rest = rest[1:], ""
+			} else {
+				raw, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+
+			raw, rest = rest[:end], rest[end:]
+		}
+
+		t := Term{
+			Words: Tokenize(raw),
+			Prefix: strings.HasSuffix(raw, "*"),
+		}
+
+		if len(t.Words) > 0 {
+			query.Terms = append(query.Terms, t)
+		}
+	}
+
+	if len(query.Terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	return query, nil
+}
+
+// Tokenize lower-cases the text and splits it into words of letters and
+// digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+// TSQuery renders the query in PostgreSQL to_tsquery syntax. Words only ever
+// contain letters and digits, so the result needs no further escaping.
+func (q *Query) TSQuery() string {
+	terms := make([]string, 0, len(q.Terms))
+
+	for _, t := range q.Terms {
+		words := make([]string, len(t.Words))
+		copy(words, t.Words)
+
+		if t.Prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		terms = append(terms, strings.Join(words, " <-> "))
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
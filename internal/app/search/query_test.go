@@ -0,0 +1,59 @@
+package search_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/search"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name string
+		query string
+		tsquery string
+	}{
+		{"words", "Go  Notes", "go & notes"},
+		{"phrase", `"rest api" go`, "rest <-> api & go"},
+		{"prefix", "note* api", "note:* & api"},
+		{"prefix phrase", `"rest ap*"`, "rest <-> ap:*"},
+		{"unterminated phrase", `go "rest api`, "go & rest <-> api"},
+		{"punctuation", "drop'; table--", "drop & table"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := search.Parse(tc.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.tsquery, q.TSQuery())
+		})
+	}
+
+	_, err := search.Parse(` "" * `)
+	assert.Equal(t, search.ErrEmptyQuery, err)
+}
+
+func TestQuery_Rank(t *testing.T) {
+	q, _ := search.Parse(`"rest api" note*`)
+
+	_, ok := q.Rank("Notes", "about a rest client")
+	assert.False(t, ok)
+
+	inText, ok := q.Rank("Notes", "about a rest api")
+	assert.True(t, ok)
+
+	inHeading, ok := q.Rank("REST API notes", "about a rest api")
+	assert.True(t, ok)
+	assert.Greater(t, inHeading, inText)
+}
+
+func TestQuery_Highlight(t *testing.T) {
+	q, _ := search.Parse(`"rest api" note*`)
+
+	assert.Equal(t, "My <mark>notes</mark> on the <mark>REST</mark> <mark>API</mark>.", q.Highlight("My notes on the REST API.", false))
+
+	long := "one two three four five six seven eight nine ten eleven twelve note"
+	assert.Equal(t, "ten eleven twelve <mark>note</mark>", q.Highlight(long, true))
+
+	assert.Equal(t, "&lt;script&gt;alert(&#34;<mark>note</mark>&#34;)&lt;/script&gt;", q.Highlight(`<script>alert("note")</script>`, false))
+	assert.Equal(t, "&lt;&gt;", q.Highlight("<>", true))
+}
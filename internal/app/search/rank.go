@@ -0,0 +1,133 @@
+package search
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Weights of a match in the heading and in the text, the same as the default
+// weights ts_rank uses for the A and B labels.
+const (
+	headingWeight = 1.0
+	textWeight = 0.4
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop = "</mark>"
+	snippetWords = 30
+)
+
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Rank reports whether the heading and text match every term of the query and
+// how relevant they are. Longer documents score lower for the same number of
+// matches.
+func (q *Query) Rank(heading string, text string) (float64, bool) {
+	headingTokens := Tokenize(heading)
+	textTokens := Tokenize(text)
+
+	score := 0.0
+	for _, t := range q.Terms {
+		h := len(t.matches(headingTokens))
+		x := len(t.matches(textTokens))
+
+		if h+x == 0 {
+			return 0, false
+		}
+
+		score += headingWeight*float64(h) + textWeight*float64(x)
+	}
+
+	return score / (1 + math.Log(float64(1+len(headingTokens)+len(textTokens)))), true
+}
+
+// Highlight wraps every word of the text that is part of a match in <mark>
+// tags. With snippet set, only a window of words around the first match is
+// returned. The text itself is HTML-escaped, so that the result can be
+// rendered as HTML without running the markup an author put in an article.
+func (q *Query) Highlight(text string, snippet bool) string {
+	locs := wordRe.FindAllStringIndex(text, -1)
+
+	tokens := make([]string, len(locs))
+	for i, loc := range locs {
+		tokens[i] = strings.ToLower(text[loc[0]:loc[1]])
+	}
+
+	marked := make([]bool, len(tokens))
+	first := -1
+	for _, t := range q.Terms {
+		for _, start := range t.matches(tokens) {
+			for i := start; i < start+len(t.Words); i++ {
+				marked[i] = true
+			}
+
+			if first < 0 || start < first {
+				first = start
+			}
+		}
+	}
+
+	from, to := 0, len(tokens)
+	if snippet {
+		if first > 3 {
+			from = first - 3
+		}
+
+		if from+snippetWords < to {
+			to = from + snippetWords
+		}
+	}
+
+	if len(tokens) == 0 {
+		return html.EscapeString(text)
+	}
+
+	sb := &strings.Builder{}
+	start := 0
+	end := len(text)
+	if snippet {
+		start = locs[from][0]
+		end = locs[to-1][1]
+	}
+
+	pos := start
+	for i := from; i < to; i++ {
+		if !marked[i] {
+			continue
+		}
+
+		sb.WriteString(html.EscapeString(text[pos:locs[i][0]]))
+		sb.WriteString(highlightStart)
+		sb.WriteString(text[locs[i][0]:locs[i][1]])
+		sb.WriteString(highlightStop)
+		pos = locs[i][1]
+	}
+	sb.WriteString(html.EscapeString(text[pos:end]))
+
+	return sb.String()
+}
+
+// matches returns the positions in tokens where the term starts.
+func (t Term) matches(tokens []string) []int {
+	found := make([]int, 0)
+
+	for i := 0; i+len(t.Words) <= len(tokens); i++ {
+		ok := true
+		for j, w := range t.Words {
+			last := j == len(t.Words)-1
+			if tokens[i+j] != w && !(last && t.Prefix && strings.HasPrefix(tokens[i+j], w)) {
+				ok = false
+				break
+			}
+		}
+
+		if ok {
+			found = append(found, i)
+		}
+	}
+
+	return found
+}
@@ -1,8 +1,97 @@
 package store
 
-import "errors"
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"sort"
+	"strings"
+)
+
+// Repositories report the failures a caller can act on with the error types
+// below, whatever the backend, so that they can be told apart with
+// errors.As. Any other error is internal and not meant for users.
+
+// NotFoundError means that the record does not exist.
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
+// ConflictError means that a write clashes with the stored data, such as a
+// stale version or a second record with a unique value.
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// ValidationError means that the data was refused. Fields maps each invalid
+// field, by its JSON name, to what is wrong with it. Err is the error it was
+// made from, if any.
+type ValidationError struct {
+	Message string
+	Fields map[string]string
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name + ": " + e.Fields[name])
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
 
 var(
-	ErrRecordNotFound = errors.New("record not found")
-	ErrCreate = errors.New("create error")
+	ErrRecordNotFound error = &NotFoundError{"record not found"}
+	ErrEditConflict error = &ConflictError{"record was changed by someone else"}
+	ErrAlreadyExists error = &ConflictError{"record already exists"}
+	ErrInvalidReference error = &ValidationError{Message: "referenced record does not exist"}
 )
+
+// NewValidationError turns an error returned by a model's Validate into a
+// *ValidationError, keeping the per-field details of validation.Errors.
+// A nil error stays nil.
+func NewValidationError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var internal validation.InternalError
+	if errors.As(err, &internal) {
+		return err
+	}
+
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		fields := make(map[string]string, len(errs))
+		for name, fieldErr := range errs {
+			fields[name] = fieldErr.Error()
+		}
+
+		return &ValidationError{Message: "validation failed", Fields: fields, Err: err}
+	}
+
+	return &ValidationError{Message: err.Error(), Err: err}
+}
@@ -0,0 +1,121 @@
+package metricstore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type ArticleRepository struct {
+	next store.ArticleRepository
+	metrics *Metrics
+}
+
+func (r *ArticleRepository) CreateArticle(ctx context.Context, a *model.Article) (err error) {
+	defer r.metrics.observe("article", "CreateArticle", time.Now(), &err)
+
+	return r.next.CreateArticle(ctx, a)
+}
+
+func (r *ArticleRepository) Find(ctx context.Context, id int) (a *model.Article, err error) {
+	defer r.metrics.observe("article", "Find", time.Now(), &err)
+
+	return r.next.Find(ctx, id)
+}
+
+func (r *ArticleRepository) FindByHeading(ctx context.Context, header string, aud model.Audience) (a *model.Article, err error) {
+	defer r.metrics.observe("article", "FindByHeading", time.Now(), &err)
+
+	return r.next.FindByHeading(ctx, header, aud)
+}
+
+func (r *ArticleRepository) List(ctx context.Context, f *model.ArticleFilter) (ars []*model.Article, next *model.ArticleCursor, err error) {
+	defer r.metrics.observe("article", "List", time.Now(), &err)
+
+	return r.next.List(ctx, f)
+}
+
+func (r *ArticleRepository) DeleteArticle(ctx context.Context, id int, version int) (heading string, err error) {
+	defer r.metrics.observe("article", "DeleteArticle", time.Now(), &err)
+
+	return r.next.DeleteArticle(ctx, id, version)
+}
+
+func (r *ArticleRepository) ChangeArticleById(ctx context.Context, a *model.Article) (err error) {
+	defer r.metrics.observe("article", "ChangeArticleById", time.Now(), &err)
+
+	return r.next.ChangeArticleById(ctx, a)
+}
+
+func (r *ArticleRepository) MoveToNotebook(ctx context.Context, a *model.Article) (err error) {
+	defer r.metrics.observe("article", "MoveToNotebook", time.Now(), &err)
+
+	return r.next.MoveToNotebook(ctx, a)
+}
+
+func (r *ArticleRepository) MoveAllToNotebook(ctx context.Context, fromID int, toID int) (err error) {
+	defer r.metrics.observe("article", "MoveAllToNotebook", time.Now(), &err)
+
+	return r.next.MoveAllToNotebook(ctx, fromID, toID)
+}
+
+func (r *ArticleRepository) MoveTrashToNotebook(ctx context.Context, fromID int, toID int) (err error) {
+	defer r.metrics.observe("article", "MoveTrashToNotebook", time.Now(), &err)
+
+	return r.next.MoveTrashToNotebook(ctx, fromID, toID)
+}
+
+func (r *ArticleRepository) TrashAllInNotebook(ctx context.Context, notebookID int) (err error) {
+	defer r.metrics.observe("article", "TrashAllInNotebook", time.Now(), &err)
+
+	return r.next.TrashAllInNotebook(ctx, notebookID)
+}
+
+func (r *ArticleRepository) UpdateStatus(ctx context.Context, a *model.Article) (err error) {
+	defer r.metrics.observe("article", "UpdateStatus", time.Now(), &err)
+
+	return r.next.UpdateStatus(ctx, a)
+}
+
+func (r *ArticleRepository) PublishDue(ctx context.Context, now time.Time) (n int, err error) {
+	defer r.metrics.observe("article", "PublishDue", time.Now(), &err)
+
+	return r.next.PublishDue(ctx, now)
+}
+
+func (r *ArticleRepository) ListTrash(ctx context.Context, authorID int) (ars []*model.Article, err error) {
+	defer r.metrics.observe("article", "ListTrash", time.Now(), &err)
+
+	return r.next.ListTrash(ctx, authorID)
+}
+
+func (r *ArticleRepository) FindTrashed(ctx context.Context, id int) (a *model.Article, err error) {
+	defer r.metrics.observe("article", "FindTrashed", time.Now(), &err)
+
+	return r.next.FindTrashed(ctx, id)
+}
+
+func (r *ArticleRepository) Restore(ctx context.Context, a *model.Article) (err error) {
+	defer r.metrics.observe("article", "Restore", time.Now(), &err)
+
+	return r.next.Restore(ctx, a)
+}
+
+func (r *ArticleRepository) EmptyTrash(ctx context.Context, authorID int) (n int, err error) {
+	defer r.metrics.observe("article", "EmptyTrash", time.Now(), &err)
+
+	return r.next.EmptyTrash(ctx, authorID)
+}
+
+func (r *ArticleRepository) PurgeTrash(ctx context.Context, before time.Time) (n int, err error) {
+	defer r.metrics.observe("article", "PurgeTrash", time.Now(), &err)
+
+	return r.next.PurgeTrash(ctx, before)
+}
+
+func (r *ArticleRepository) Search(ctx context.Context, f *model.SearchFilter) (results []*model.SearchResult, err error) {
+	defer r.metrics.observe("article", "Search", time.Now(), &err)
+
+	return r.next.Search(ctx, f)
+}
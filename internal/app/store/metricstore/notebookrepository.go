@@ -0,0 +1,49 @@
+package metricstore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type NotebookRepository struct {
+	next store.NotebookRepository
+	metrics *Metrics
+}
+
+func (r *NotebookRepository) Create(ctx context.Context, n *model.Notebook) (err error) {
+	defer r.metrics.observe("notebook", "Create", time.Now(), &err)
+
+	return r.next.Create(ctx, n)
+}
+
+func (r *NotebookRepository) Find(ctx context.Context, id int) (n *model.Notebook, err error) {
+	defer r.metrics.observe("notebook", "Find", time.Now(), &err)
+
+	return r.next.Find(ctx, id)
+}
+
+func (r *NotebookRepository) FindByOwner(ctx context.Context, ownerID int) (ns []*model.Notebook, err error) {
+	defer r.metrics.observe("notebook", "FindByOwner", time.Now(), &err)
+
+	return r.next.FindByOwner(ctx, ownerID)
+}
+
+func (r *NotebookRepository) FindOrCreateDefault(ctx context.Context, ownerID int) (n *model.Notebook, err error) {
+	defer r.metrics.observe("notebook", "FindOrCreateDefault", time.Now(), &err)
+
+	return r.next.FindOrCreateDefault(ctx, ownerID)
+}
+
+func (r *NotebookRepository) Rename(ctx context.Context, id int, name string) (err error) {
+	defer r.metrics.observe("notebook", "Rename", time.Now(), &err)
+
+	return r.next.Rename(ctx, id, name)
+}
+
+func (r *NotebookRepository) Delete(ctx context.Context, id int) (err error) {
+	defer r.metrics.observe("notebook", "Delete", time.Now(), &err)
+
+	return r.next.Delete(ctx, id)
+}
@@ -0,0 +1,37 @@
+package metricstore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type RefreshTokenRepository struct {
+	next store.RefreshTokenRepository
+	metrics *Metrics
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, rt *model.RefreshToken) (err error) {
+	defer r.metrics.observe("refresh_token", "Create", time.Now(), &err)
+
+	return r.next.Create(ctx, rt)
+}
+
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (rt *model.RefreshToken, err error) {
+	defer r.metrics.observe("refresh_token", "FindByHash", time.Now(), &err)
+
+	return r.next.FindByHash(ctx, hash)
+}
+
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id int) (err error) {
+	defer r.metrics.observe("refresh_token", "Revoke", time.Now(), &err)
+
+	return r.next.Revoke(ctx, id)
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) (err error) {
+	defer r.metrics.observe("refresh_token", "RevokeFamily", time.Now(), &err)
+
+	return r.next.RevokeFamily(ctx, familyID)
+}
@@ -0,0 +1,31 @@
+package metricstore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type RevisionRepository struct {
+	next store.RevisionRepository
+	metrics *Metrics
+}
+
+func (r *RevisionRepository) Create(ctx context.Context, rev *model.Revision) (err error) {
+	defer r.metrics.observe("revision", "Create", time.Now(), &err)
+
+	return r.next.Create(ctx, rev)
+}
+
+func (r *RevisionRepository) Find(ctx context.Context, articleID int, number int) (rev *model.Revision, err error) {
+	defer r.metrics.observe("revision", "Find", time.Now(), &err)
+
+	return r.next.Find(ctx, articleID, number)
+}
+
+func (r *RevisionRepository) FindByArticle(ctx context.Context, articleID int) (revs []*model.Revision, err error) {
+	defer r.metrics.observe("revision", "FindByArticle", time.Now(), &err)
+
+	return r.next.FindByArticle(ctx, articleID)
+}
@@ -0,0 +1,136 @@
+// Package metricstore is a store.Store decorator that records how long every
+// repository call takes and how it ended, as Prometheus metrics.
+package metricstore
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+// Metrics holds the collectors shared by a store and the transactional
+// stores it hands to WithTx.
+type Metrics struct {
+	duration *prometheus.HistogramVec
+	calls *prometheus.CounterVec
+}
+
+// NewMetrics creates the store metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "notebook",
+			Subsystem: "store",
+			Name: "call_duration_seconds",
+			Help: "Duration of store calls by repository and method.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"repository", "method"}),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "notebook",
+			Subsystem: "store",
+			Name: "calls_total",
+			Help: "Store calls by repository, method and result.",
+		}, []string{"repository", "method", "result"}),
+	}
+
+	reg.MustRegister(m.duration, m.calls)
+
+	return m
+}
+
+// observe records a call that started at start. It is deferred with a
+// pointer to the named error result of the call.
+func (m *Metrics) observe(repository string, method string, start time.Time, err *error) {
+	m.duration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	m.calls.WithLabelValues(repository, method, result(*err)).Inc()
+}
+
+// result sorts an error into the kinds of store errors, so that an expected
+// not found is not counted as a failure.
+func result(err error) string {
+	var (
+		notFound *store.NotFoundError
+		conflict *store.ConflictError
+		validation *store.ValidationError
+	)
+
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &notFound):
+		return "not_found"
+	case errors.As(err, &conflict):
+		return "conflict"
+	case errors.As(err, &validation):
+		return "invalid"
+	}
+
+	return "error"
+}
+
+type Store struct {
+	next store.Store
+	metrics *Metrics
+	userRepository *UserRepository
+	articleRepository *ArticleRepository
+	refreshTokenRepository *RefreshTokenRepository
+	notebookRepository *NotebookRepository
+	revisionRepository *RevisionRepository
+	tagRepository *TagRepository
+}
+
+// New wraps next so that its calls are recorded in m.
+func New(next store.Store, m *Metrics) *Store {
+	return &Store{
+		next: next,
+		metrics: m,
+		userRepository: &UserRepository{next.User(), m},
+		articleRepository: &ArticleRepository{next.Article(), m},
+		refreshTokenRepository: &RefreshTokenRepository{next.RefreshToken(), m},
+		notebookRepository: &NotebookRepository{next.Notebook(), m},
+		revisionRepository: &RevisionRepository{next.Revision(), m},
+		tagRepository: &TagRepository{next.Tag(), m},
+	}
+}
+
+func (s *Store) User() store.UserRepository {
+	return s.userRepository
+}
+
+func (s *Store) Article() store.ArticleRepository {
+	return s.articleRepository
+}
+
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	return s.refreshTokenRepository
+}
+
+func (s *Store) Notebook() store.NotebookRepository {
+	return s.notebookRepository
+}
+
+func (s *Store) Revision() store.RevisionRepository {
+	return s.revisionRepository
+}
+
+func (s *Store) Tag() store.TagRepository {
+	return s.tagRepository
+}
+
+// WithTx records the whole transaction as well as the calls made in it, for
+// which fn gets the transactional store of next wrapped in turn.
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) (err error) {
+	defer s.metrics.observe("store", "WithTx", time.Now(), &err)
+
+	return s.next.WithTx(ctx, func(tx store.Store) error {
+		return fn(New(tx, s.metrics))
+	})
+}
+
+func (s *Store) Ping(ctx context.Context) (err error) {
+	defer s.metrics.observe("store", "Ping", time.Now(), &err)
+
+	return s.next.Ping(ctx)
+}
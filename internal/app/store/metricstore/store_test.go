@@ -0,0 +1,53 @@
+package metricstore_test
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/metricstore"
+	"rest_api/internal/app/store/storetest"
+	"rest_api/internal/app/store/teststore"
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	storetest.RunAll(t, func(t *testing.T) store.Store {
+		return metricstore.New(teststore.New(), metricstore.NewMetrics(prometheus.NewRegistry()))
+	})
+}
+
+func TestStore_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	s := metricstore.New(teststore.New(), metricstore.NewMetrics(reg))
+
+	u := model.TestUser(t)
+	assert.NoError(t, s.User().Create(context.Background(), u))
+
+	_, err := s.User().Find(context.Background(), u.ID + 1)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+
+	errAbort := errors.New("abort")
+	assert.Equal(t, errAbort, s.WithTx(context.Background(), func(tx store.Store) error {
+		if _, err := tx.User().Find(context.Background(), u.ID); err != nil {
+			return err
+		}
+
+		return errAbort
+	}))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP notebook_store_calls_total Store calls by repository, method and result.
+# TYPE notebook_store_calls_total counter
+notebook_store_calls_total{method="Create",repository="user",result="ok"} 1
+notebook_store_calls_total{method="Find",repository="user",result="not_found"} 1
+notebook_store_calls_total{method="Find",repository="user",result="ok"} 1
+notebook_store_calls_total{method="WithTx",repository="store",result="error"} 1
+`), "notebook_store_calls_total"))
+
+	assert.Equal(t, 3, testutil.CollectAndCount(reg, "notebook_store_call_duration_seconds"))
+}
@@ -0,0 +1,43 @@
+package metricstore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type TagRepository struct {
+	next store.TagRepository
+	metrics *Metrics
+}
+
+func (r *TagRepository) Find(ctx context.Context, id int) (t *model.Tag, err error) {
+	defer r.metrics.observe("tag", "Find", time.Now(), &err)
+
+	return r.next.Find(ctx, id)
+}
+
+func (r *TagRepository) FindByOwner(ctx context.Context, ownerID int) (ts []*model.Tag, err error) {
+	defer r.metrics.observe("tag", "FindByOwner", time.Now(), &err)
+
+	return r.next.FindByOwner(ctx, ownerID)
+}
+
+func (r *TagRepository) SetArticleTags(ctx context.Context, articleID int, ownerID int, names []string) (err error) {
+	defer r.metrics.observe("tag", "SetArticleTags", time.Now(), &err)
+
+	return r.next.SetArticleTags(ctx, articleID, ownerID, names)
+}
+
+func (r *TagRepository) Rename(ctx context.Context, id int, name string) (err error) {
+	defer r.metrics.observe("tag", "Rename", time.Now(), &err)
+
+	return r.next.Rename(ctx, id, name)
+}
+
+func (r *TagRepository) Merge(ctx context.Context, fromID int, toID int) (err error) {
+	defer r.metrics.observe("tag", "Merge", time.Now(), &err)
+
+	return r.next.Merge(ctx, fromID, toID)
+}
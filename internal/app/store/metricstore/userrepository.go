@@ -0,0 +1,31 @@
+package metricstore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type UserRepository struct {
+	next store.UserRepository
+	metrics *Metrics
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *model.User) (err error) {
+	defer r.metrics.observe("user", "Create", time.Now(), &err)
+
+	return r.next.Create(ctx, u)
+}
+
+func (r *UserRepository) Find(ctx context.Context, id int) (u *model.User, err error) {
+	defer r.metrics.observe("user", "Find", time.Now(), &err)
+
+	return r.next.Find(ctx, id)
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (u *model.User, err error) {
+	defer r.metrics.observe("user", "FindByEmail", time.Now(), &err)
+
+	return r.next.FindByEmail(ctx, email)
+}
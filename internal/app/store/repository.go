@@ -1,16 +1,65 @@
 package store
 
-import "rest_api/internal/app/model"
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"time"
+)
 
 type UserRepository interface {
-	Create(*model.User) error
-	FindByEmail(string) (*model.User, error)
+	Create(context.Context, *model.User) error
+	Find(context.Context, int) (*model.User, error)
+	FindByEmail(context.Context, string) (*model.User, error)
 }
 
 type ArticleRepository interface {
-	CreateArticle(*model.Article) error
-	FindByHeading(string) (*model.Article, error)
-	ShowAllArticles() ([]*model.Article, error)
-	DeleteArticle(int) (string, error)
-	ChangeArticleById(*model.Article) error
+	CreateArticle(context.Context, *model.Article) error
+	Find(context.Context, int) (*model.Article, error)
+	FindByHeading(context.Context, string, model.Audience) (*model.Article, error)
+	List(context.Context, *model.ArticleFilter) ([]*model.Article, *model.ArticleCursor, error)
+	DeleteArticle(context.Context, int, int) (string, error)
+	ChangeArticleById(context.Context, *model.Article) error
+	MoveToNotebook(context.Context, *model.Article) error
+	MoveAllToNotebook(context.Context, int, int) error
+	MoveTrashToNotebook(context.Context, int, int) error
+	TrashAllInNotebook(context.Context, int) error
+	UpdateStatus(context.Context, *model.Article) error
+	PublishDue(context.Context, time.Time) (int, error)
+	ListTrash(context.Context, int) ([]*model.Article, error)
+	FindTrashed(context.Context, int) (*model.Article, error)
+	Restore(context.Context, *model.Article) error
+	EmptyTrash(context.Context, int) (int, error)
+	PurgeTrash(context.Context, time.Time) (int, error)
+	Search(context.Context, *model.SearchFilter) ([]*model.SearchResult, error)
 }
+
+type RefreshTokenRepository interface {
+	Create(context.Context, *model.RefreshToken) error
+	FindByHash(context.Context, string) (*model.RefreshToken, error)
+	Revoke(context.Context, int) error
+	RevokeFamily(context.Context, string) error
+}
+
+type NotebookRepository interface {
+	Create(context.Context, *model.Notebook) error
+	Find(context.Context, int) (*model.Notebook, error)
+	FindByOwner(context.Context, int) ([]*model.Notebook, error)
+	FindOrCreateDefault(context.Context, int) (*model.Notebook, error)
+	Rename(context.Context, int, string) error
+	Delete(context.Context, int) error
+}
+
+type RevisionRepository interface {
+	Create(context.Context, *model.Revision) error
+	Find(context.Context, int, int) (*model.Revision, error)
+	FindByArticle(context.Context, int) ([]*model.Revision, error)
+}
+
+
+type TagRepository interface {
+	Find(context.Context, int) (*model.Tag, error)
+	FindByOwner(context.Context, int) ([]*model.Tag, error)
+	SetArticleTags(context.Context, int, int, []string) error
+	Rename(context.Context, int, string) error
+	Merge(context.Context, int, int) error
+}
\ No newline at end of file
@@ -0,0 +1,34 @@
+package sqlitestore
+
+import (
+	"errors"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"rest_api/internal/app/store"
+	"strings"
+)
+
+// storeError translates constraint violations reported by SQLite into the
+// store's errors, so that driver messages do not reach callers. Other errors
+// are returned unchanged.
+func storeError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return store.ErrAlreadyExists
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return store.ErrInvalidReference
+	}
+
+	// A statement with RETURNING reports a violated foreign key as a plain
+	// SQL logic error, so the message has to be consulted.
+	if strings.Contains(sqliteErr.Error(), "FOREIGN KEY constraint failed") {
+		return store.ErrInvalidReference
+	}
+
+	return err
+}
@@ -0,0 +1,162 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+)
+
+type NotebookRepository struct {
+	store *Store
+}
+
+func (r *NotebookRepository) Create(ctx context.Context, n *model.Notebook) (err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Create")
+	defer endSpan(span, &err)
+
+	if err := n.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	err = r.store.db.QueryRowContext(ctx, 
+		"INSERT INTO notebooks (name, owner_id) VALUES ($1, $2) RETURNING id, is_default, created_at",
+		n.Name,
+		n.OwnerID,
+	).Scan(
+		&n.ID,
+		&n.IsDefault,
+		&n.CreatedAt,
+	)
+
+	return storeError(err)
+}
+
+func (r *NotebookRepository) Find(ctx context.Context, id int) (_ *model.Notebook, err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Find")
+	defer endSpan(span, &err)
+
+	n := &model.Notebook{}
+
+	if err := r.store.db.QueryRowContext(ctx, 
+		"SELECT id, name, owner_id, is_default, created_at FROM notebooks WHERE id = $1",
+		id,
+	).Scan(
+		&n.ID,
+		&n.Name,
+		&n.OwnerID,
+		&n.IsDefault,
+		&n.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (r *NotebookRepository) FindByOwner(ctx context.Context, ownerID int) (_ []*model.Notebook, err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.FindByOwner")
+	defer endSpan(span, &err)
+
+	rows, err := r.store.db.QueryContext(ctx, 
+		"SELECT id, name, owner_id, is_default, created_at FROM notebooks WHERE owner_id = $1 ORDER BY id",
+		ownerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ns := make([]*model.Notebook, 0)
+	for rows.Next() {
+		n := &model.Notebook{}
+		if err := rows.Scan(
+			&n.ID,
+			&n.Name,
+			&n.OwnerID,
+			&n.IsDefault,
+			&n.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		ns = append(ns, n)
+	}
+
+	return ns, rows.Err()
+}
+
+func (r *NotebookRepository) FindOrCreateDefault(ctx context.Context, ownerID int) (_ *model.Notebook, err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.FindOrCreateDefault")
+	defer endSpan(span, &err)
+
+	n := &model.Notebook{}
+
+	if err := r.store.transact(ctx, func(tx *Store) error {
+		if _, err := tx.db.ExecContext(ctx, 
+			"INSERT INTO notebooks (name, owner_id, is_default) VALUES ($1, $2, true) ON CONFLICT (owner_id) WHERE is_default DO NOTHING",
+			model.DefaultNotebookName,
+			ownerID,
+		); err != nil {
+			return err
+		}
+
+		return tx.db.QueryRowContext(ctx, 
+			"SELECT id, name, owner_id, is_default, created_at FROM notebooks WHERE owner_id = $1 AND is_default",
+			ownerID,
+		).Scan(
+			&n.ID,
+			&n.Name,
+			&n.OwnerID,
+			&n.IsDefault,
+			&n.CreatedAt,
+		)
+	}); err != nil {
+		return nil, storeError(err)
+	}
+
+	return n, nil
+}
+
+func (r *NotebookRepository) Rename(ctx context.Context, id int, name string) (err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Rename")
+	defer endSpan(span, &err)
+
+	n := &model.Notebook{Name: name}
+	if err := n.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	return r.exec(ctx, "UPDATE notebooks SET name = $1 WHERE id = $2", name, id)
+}
+
+// Delete takes the articles of the notebook with it only because Open turns
+// on foreign keys, which SQLite leaves off by default.
+func (r *NotebookRepository) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Delete")
+	defer endSpan(span, &err)
+
+	return r.exec(ctx, "DELETE FROM notebooks WHERE id = $1", id)
+}
+
+func (r *NotebookRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+	res, err := r.store.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, t *model.RefreshToken) (err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.Create")
+	defer endSpan(span, &err)
+
+	err = r.store.db.QueryRowContext(ctx, 
+		"INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		t.UserID,
+		t.TokenHash,
+		t.FamilyID,
+		t.ExpiresAt.UTC(),
+	).Scan(
+		&t.ID,
+		&t.CreatedAt,
+	)
+
+	return storeError(err)
+}
+
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (_ *model.RefreshToken, err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.FindByHash")
+	defer endSpan(span, &err)
+
+	t := &model.RefreshToken{}
+	var revokedAt sql.NullTime
+
+	if err := r.store.db.QueryRowContext(ctx, 
+		"SELECT id, user_id, token_hash, family_id, expires_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1",
+		hash,
+	).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.TokenHash,
+		&t.FamilyID,
+		&t.ExpiresAt,
+		&revokedAt,
+		&t.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+
+	return t, nil
+}
+
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.Revoke")
+	defer endSpan(span, &err)
+
+	res, err := r.store.db.ExecContext(ctx, 
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL",
+		time.Now().UTC(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) (err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.RevokeFamily")
+	defer endSpan(span, &err)
+
+	_, err = r.store.db.ExecContext(ctx, 
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+		time.Now().UTC(),
+		familyID,
+	)
+
+	return err
+}
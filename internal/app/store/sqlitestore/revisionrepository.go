@@ -0,0 +1,96 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+)
+
+type RevisionRepository struct {
+	store *Store
+}
+
+func (r *RevisionRepository) Create(ctx context.Context, rev *model.Revision) (err error) {
+	ctx, span := startSpan(ctx, "RevisionRepository.Create")
+	defer endSpan(span, &err)
+
+	err = r.store.db.QueryRowContext(ctx, 
+		`INSERT INTO article_revisions (article_id, revision, article_header, article_text, author_id)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4 FROM article_revisions WHERE article_id = $1
+		RETURNING id, revision, created_at`,
+		rev.ArticleID,
+		rev.Heading,
+		rev.Text,
+		rev.AuthorID,
+	).Scan(
+		&rev.ID,
+		&rev.Number,
+		&rev.CreatedAt,
+	)
+
+	return storeError(err)
+}
+
+func (r *RevisionRepository) Find(ctx context.Context, articleID int, number int) (_ *model.Revision, err error) {
+	ctx, span := startSpan(ctx, "RevisionRepository.Find")
+	defer endSpan(span, &err)
+
+	rev := &model.Revision{}
+
+	if err := r.store.db.QueryRowContext(ctx, 
+		"SELECT id, article_id, revision, article_header, article_text, author_id, created_at FROM article_revisions WHERE article_id = $1 AND revision = $2",
+		articleID,
+		number,
+	).Scan(
+		&rev.ID,
+		&rev.ArticleID,
+		&rev.Number,
+		&rev.Heading,
+		&rev.Text,
+		&rev.AuthorID,
+		&rev.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+func (r *RevisionRepository) FindByArticle(ctx context.Context, articleID int) (_ []*model.Revision, err error) {
+	ctx, span := startSpan(ctx, "RevisionRepository.FindByArticle")
+	defer endSpan(span, &err)
+
+	rows, err := r.store.db.QueryContext(ctx, 
+		"SELECT id, article_id, revision, article_header, article_text, author_id, created_at FROM article_revisions WHERE article_id = $1 ORDER BY revision",
+		articleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revs := make([]*model.Revision, 0)
+	for rows.Next() {
+		rev := &model.Revision{}
+		if err := rows.Scan(
+			&rev.ID,
+			&rev.ArticleID,
+			&rev.Number,
+			&rev.Heading,
+			&rev.Text,
+			&rev.AuthorID,
+			&rev.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		revs = append(revs, rev)
+	}
+
+	return revs, rows.Err()
+}
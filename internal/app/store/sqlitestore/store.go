@@ -0,0 +1,167 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	_ "modernc.org/sqlite"
+	"rest_api/internal/app/logging"
+	"rest_api/internal/app/store"
+)
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Store keeps everything in a single SQLite database file. It is meant for
+// personal deployments where running PostgreSQL is not worth it.
+//
+// The repositories keep the contracts documented in sqlstore, and their
+// comments only note where SQLite differs. SQLite has no now() and compares
+// times as text, so times are bound from Go in UTC instead.
+type Store struct {
+	db querier
+	conn *sql.DB
+	userRepository *UserRepository
+	articleRepository *ArticleRepository
+	refreshTokenRepository *RefreshTokenRepository
+	notebookRepository *NotebookRepository
+	revisionRepository *RevisionRepository
+	tagRepository *TagRepository
+}
+
+func New(db *sql.DB) *Store {
+	return &Store {
+		db: db,
+		conn: db,
+	}
+}
+
+// Open opens the database file at path, creating it if it does not exist,
+// with foreign keys enforced.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer at a time, so a single connection
+	// queues writers instead of failing them with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (s *Store) User() store.UserRepository {
+	if s.userRepository == nil {
+		s.userRepository = &UserRepository{
+			s,
+		}
+	}
+
+	return s.userRepository
+}
+
+func (s *Store) Article() store.ArticleRepository {
+	if s.articleRepository == nil {
+		s.articleRepository = &ArticleRepository{
+			s,
+		}
+	}
+
+	return s.articleRepository
+}
+
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository == nil {
+		s.refreshTokenRepository = &RefreshTokenRepository{
+			s,
+		}
+	}
+
+	return s.refreshTokenRepository
+}
+
+func (s *Store) Notebook() store.NotebookRepository {
+	if s.notebookRepository == nil {
+		s.notebookRepository = &NotebookRepository{
+			s,
+		}
+	}
+
+	return s.notebookRepository
+}
+
+func (s *Store) Revision() store.RevisionRepository {
+	if s.revisionRepository == nil {
+		s.revisionRepository = &RevisionRepository{
+			s,
+		}
+	}
+
+	return s.revisionRepository
+}
+
+func (s *Store) Tag() store.TagRepository {
+	if s.tagRepository == nil {
+		s.tagRepository = &TagRepository{
+			s,
+		}
+	}
+
+	return s.tagRepository
+}
+
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.transact(ctx, func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	if s.conn != nil {
+		return s.conn.PingContext(ctx)
+	}
+
+	_, err := s.db.ExecContext(ctx, "SELECT 1")
+
+	return err
+}
+
+func (s *Store) transact(ctx context.Context, fn func(*Store) error) (err error) {
+	if s.conn == nil {
+		return fn(s)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("rolling back transaction", "error", rbErr, "cause", err)
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return fn(&Store {
+		db: tx,
+	})
+}
@@ -0,0 +1,19 @@
+package sqlitestore_test
+
+import (
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/sqlitestore"
+	"rest_api/internal/app/store/storetest"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	storetest.RunAll(t, func(t *testing.T) store.Store {
+		db, teardown := sqlitestore.TestDB(t)
+		t.Cleanup(func() {
+			teardown()
+		})
+
+		return sqlitestore.New(db)
+	})
+}
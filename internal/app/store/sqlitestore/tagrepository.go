@@ -0,0 +1,196 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+)
+
+type TagRepository struct {
+	store *Store
+}
+
+func (r *TagRepository) Find(ctx context.Context, id int) (_ *model.Tag, err error) {
+	ctx, span := startSpan(ctx, "TagRepository.Find")
+	defer endSpan(span, &err)
+
+	t := &model.Tag{}
+
+	if err := r.store.db.QueryRowContext(ctx, 
+		"SELECT t.id, t.name, t.owner_id, (SELECT count(*) FROM article_tags at JOIN articles a ON a.id = at.article_id WHERE at.tag_id = t.id AND a.deleted_at IS NULL) FROM tags t WHERE t.id = $1",
+		id,
+	).Scan(
+		&t.ID,
+		&t.Name,
+		&t.OwnerID,
+		&t.ArticleCount,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (r *TagRepository) FindByOwner(ctx context.Context, ownerID int) (_ []*model.Tag, err error) {
+	ctx, span := startSpan(ctx, "TagRepository.FindByOwner")
+	defer endSpan(span, &err)
+
+	rows, err := r.store.db.QueryContext(ctx, 
+		"SELECT t.id, t.name, t.owner_id, count(a.id) FROM tags t LEFT JOIN article_tags at ON at.tag_id = t.id LEFT JOIN articles a ON a.id = at.article_id AND a.deleted_at IS NULL WHERE t.owner_id = $1 GROUP BY t.id ORDER BY t.name",
+		ownerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := make([]*model.Tag, 0)
+	for rows.Next() {
+		t := &model.Tag{}
+		if err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.OwnerID,
+			&t.ArticleCount,
+		); err != nil {
+			return nil, err
+		}
+
+		ts = append(ts, t)
+	}
+
+	return ts, rows.Err()
+}
+
+func (r *TagRepository) SetArticleTags(ctx context.Context, articleID int, ownerID int, names []string) (err error) {
+	ctx, span := startSpan(ctx, "TagRepository.SetArticleTags")
+	defer endSpan(span, &err)
+
+	names, err = model.NormalizeTags(names)
+	if err != nil {
+		return store.NewValidationError(err)
+	}
+
+	err = r.store.transact(ctx, func(tx *Store) error {
+		if _, err := tx.db.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id = $1", articleID); err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			var tagID int
+
+			if err := tx.db.QueryRowContext(ctx, 
+				"INSERT INTO tags (name, owner_id) VALUES ($1, $2) ON CONFLICT (owner_id, name) DO UPDATE SET name = EXCLUDED.name RETURNING id",
+				name,
+				ownerID,
+			).Scan(&tagID); err != nil {
+				return err
+			}
+
+			if _, err := tx.db.ExecContext(ctx, 
+				"INSERT INTO article_tags (article_id, tag_id) VALUES ($1, $2)",
+				articleID,
+				tagID,
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return storeError(err)
+}
+
+func (r *TagRepository) Rename(ctx context.Context, id int, name string) (err error) {
+	ctx, span := startSpan(ctx, "TagRepository.Rename")
+	defer endSpan(span, &err)
+
+	names, err := model.NormalizeTags([]string{name})
+	if err != nil {
+		return store.NewValidationError(err)
+	}
+
+	return r.store.transact(ctx, func(tx *Store) error {
+		var taken bool
+		if err := tx.db.QueryRowContext(ctx, 
+			"SELECT exists(SELECT 1 FROM tags t JOIN tags o ON o.owner_id = t.owner_id WHERE t.id = $1 AND o.name = $2 AND o.id <> t.id)",
+			id,
+			names[0],
+		).Scan(&taken); err != nil {
+			return err
+		}
+
+		if taken {
+			return store.ErrAlreadyExists
+		}
+
+		res, err := tx.db.ExecContext(ctx, "UPDATE tags SET name = $1 WHERE id = $2", names[0], id)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return store.ErrRecordNotFound
+		}
+
+		if _, err := tx.db.ExecContext(ctx, 
+			"UPDATE articles SET version = version + 1 WHERE id IN (SELECT article_id FROM article_tags WHERE tag_id = $1)",
+			id,
+		); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Merge skips the articles that already carry toID with INSERT OR IGNORE,
+// the SQLite spelling of ON CONFLICT DO NOTHING.
+func (r *TagRepository) Merge(ctx context.Context, fromID int, toID int) (err error) {
+	ctx, span := startSpan(ctx, "TagRepository.Merge")
+	defer endSpan(span, &err)
+
+	return r.store.transact(ctx, func(tx *Store) error {
+		if _, err := tx.db.ExecContext(ctx, 
+			"INSERT OR IGNORE INTO article_tags (article_id, tag_id) SELECT article_id, $2 FROM article_tags WHERE tag_id = $1",
+			fromID,
+			toID,
+		); err != nil {
+			return err
+		}
+
+		if _, err := tx.db.ExecContext(ctx, 
+			"UPDATE articles SET version = version + 1 WHERE id IN (SELECT article_id FROM article_tags WHERE tag_id = $1)",
+			fromID,
+		); err != nil {
+			return err
+		}
+
+		res, err := tx.db.ExecContext(ctx, "DELETE FROM tags WHERE id = $1", fromID)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return store.ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,34 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"path/filepath"
+	"rest_api/internal/app/migrate"
+	"rest_api/migrations"
+	"testing"
+)
+
+// TestDB opens a migrated database in a temporary file that is removed with
+// the test. The returned teardown has the signature of sqlstore.TestDB's, the
+// tables are not needed since every test starts with an empty database.
+func TestDB(t *testing.T) (*sql.DB, func(...string)) {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrate.New(db, migrations.SQLite())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	return db, func(...string) {
+		db.Close()
+	}
+}
@@ -0,0 +1,40 @@
+package sqlitestore
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"rest_api/internal/app/store"
+)
+
+const instrumentationName = "rest_api/internal/app/store/sqlitestore"
+
+// startSpan starts the span of a repository call, named like the spans of
+// sqlstore so that traces look the same whichever database is used. Only
+// db.system tells them apart.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemSqlite,
+			semconv.DBOperation(name),
+		),
+	)
+}
+
+// endSpan ends span with the error the call returned, leaving the status of
+// not found errors unset.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		var notFound *store.NotFoundError
+		if !errors.As(*err, &notFound) {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+
+	span.End()
+}
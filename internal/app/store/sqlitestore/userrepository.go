@@ -0,0 +1,89 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func (ur *UserRepository) Create(ctx context.Context, u *model.User) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Create")
+	defer endSpan(span, &err)
+
+	err = u.Validate()
+	if err != nil {
+		return store.NewValidationError(err)
+	}
+
+	err = u.BeforeCreate()
+	if err != nil {
+		return err
+	}
+
+	err = ur.store.db.QueryRowContext(ctx, 
+		"INSERT INTO users (name, email, encrypted_password, role) VALUES ($1, $2, $3, $4) RETURNING id",
+		u.Name,
+		u.Email,
+		u.EncryptedPassword,
+		u.Role,
+	).Scan(&u.ID)
+
+	return storeError(err)
+}
+
+func (ur *UserRepository) Find(ctx context.Context, id int) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Find")
+	defer endSpan(span, &err)
+
+	u := model.User{}
+
+	if err := ur.store.db.QueryRowContext(ctx, 
+		"SELECT id, name, email, encrypted_password, role FROM users where id = $1",
+		id,
+	).Scan(
+		&u.ID,
+		&u.Name,
+		&u.Email,
+		&u.EncryptedPassword,
+		&u.Role,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (ur *UserRepository) FindByEmail(ctx context.Context, email string) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByEmail")
+	defer endSpan(span, &err)
+
+	u := model.User{}
+
+	if err := ur.store.db.QueryRowContext(ctx, 
+		"SELECT id, name, email, encrypted_password, role FROM users where email = $1",
+		email,
+	).Scan(
+		&u.ID,
+		&u.Name,
+		&u.Email,
+		&u.EncryptedPassword,
+		&u.Role,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return &u, nil
+}
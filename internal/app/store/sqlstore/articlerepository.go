@@ -1,94 +1,654 @@
 package sqlstore
 
 import (
-	_ "database/sql"
+	"context"
+	"database/sql"
+	"fmt"
 	"rest_api/internal/app/model"
+	"rest_api/internal/app/search"
+	"rest_api/internal/app/store"
+	"strings"
+	"time"
 )
 
 type ArticleRepository struct {
 	store *Store
 }
 
+// articleColumns is the select list that articleFields scans.
+const articleColumns = "a.id, a.article_header, a.article_text, a.author_id, u.name, a.notebook_id, a.creating_date, a.version, a.status, a.visibility, a.publish_at, a.published_at, a.deleted_at"
 
-func (a *ArticleRepository) CreateArticle(ar *model.Article) error {
-	return a.store.db.QueryRow(
-		"INSERT INTO articles(article_header, article_text, author_id, creating_date) values ($1, $2, $3, now()::DATE) RETURNING id, creating_date",
+func articleFields(ar *model.Article) []interface{} {
+	return []interface{}{
+		&ar.ID,
+		&ar.Heading,
+		&ar.Text,
+		&ar.AuthorID,
+		&ar.AuthorName,
+		&ar.NotebookID,
+		&ar.Date,
+		&ar.Version,
+		&ar.Status,
+		&ar.Visibility,
+		&ar.PublishAt,
+		&ar.PublishedAt,
+		&ar.DeletedAt,
+	}
+}
+
+func (a *ArticleRepository) CreateArticle(ctx context.Context, ar *model.Article) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.CreateArticle")
+	defer endSpan(span, &err)
+
+	if err := ar.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	if ar.NotebookID == 0 {
+		n, err := a.store.Notebook().FindOrCreateDefault(ctx, ar.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		ar.NotebookID = n.ID
+	}
+
+	ar.BeforeCreate()
+
+	err = a.store.db.QueryRowContext(ctx, 
+		"INSERT INTO articles(article_header, article_text, author_id, notebook_id, creating_date, status, visibility, publish_at, published_at) values ($1, $2, $3, $4, now()::DATE, $5, $6, $7, $8) RETURNING id, creating_date, version",
 		&ar.Heading,
 		&ar.Text,
 		&ar.AuthorID,
+		&ar.NotebookID,
+		&ar.Status,
+		&ar.Visibility,
+		ar.PublishAt,
+		ar.PublishedAt,
 	).Scan(
 		&ar.ID,
 		&ar.Date,
+		&ar.Version,
 	)
+
+	return storeError(err)
+}
+
+func (a *ArticleRepository) Find(ctx context.Context, id int) (_ *model.Article, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.Find")
+	defer endSpan(span, &err)
+
+	ar := &model.Article{}
+
+	if err := a.store.db.QueryRowContext(ctx, 
+		"select "+articleColumns+" from articles a left join users u on u.id=a.author_id where a.id=$1 and a.deleted_at is null",
+		id,
+	).Scan(articleFields(ar)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if err := a.loadTags(ctx, []*model.Article{ar}); err != nil {
+		return nil, err
+	}
+
+	return ar, nil
 }
 
-func (a *ArticleRepository) FindByHeading(header string) (*model.Article, error) {
+// FindByHeading returns the live article with the heading that aud may read.
+// Headings are not unique; of several readable matches the oldest one wins.
+func (a *ArticleRepository) FindByHeading(ctx context.Context, header string, aud model.Audience) (_ *model.Article, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.FindByHeading")
+	defer endSpan(span, &err)
+
+	readable := "((a.status = 'published' and a.visibility <> 'private') or a.author_id = $2)"
+	if aud.All {
+		readable = "(a.visibility <> 'private' or a.author_id = $2)"
+	}
+
 	ar := &model.Article{}
 
-	if err := a.store.db.QueryRow(
-		"select a.id, a.article_header, a.article_text, u.name, a.creating_date from articles a left join users u on u.id=a.author_id where a.article_header=$1",
+	if err := a.store.db.QueryRowContext(ctx, 
+		"select "+articleColumns+" from articles a left join users u on u.id=a.author_id where a.article_header=$1 and a.deleted_at is null and "+readable+" order by a.id limit 1",
 		header,
+		aud.ViewerID,
+	).Scan(articleFields(ar)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if err := a.loadTags(ctx, []*model.Article{ar}); err != nil {
+		return nil, err
+	}
+
+	return ar, nil
+}
+
+// DeleteArticle moves the article to the trash if it is still at the given
+// version. Trashed articles are left out of every read until they are
+// restored or purged.
+func (a *ArticleRepository) DeleteArticle(ctx context.Context, id int, version int) (_ string, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.DeleteArticle")
+	defer endSpan(span, &err)
+
+	var articleHeader string
+
+	if err := a.store.db.QueryRowContext(ctx, 
+		"UPDATE articles SET deleted_at=now(), version=version+1 where id=$1 and version=$2 and deleted_at is null returning article_header",
+		id,
+		version,
+	).Scan(
+		&articleHeader,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return "", a.missingOrConflict(ctx, id)
+		}
+
+		return "", err
+	}
+
+	return articleHeader, nil
+}
+
+// ChangeArticleById overwrites the article if it is still at ar.Version and
+// bumps the version. A stale version results in store.ErrEditConflict.
+func (a *ArticleRepository) ChangeArticleById(ctx context.Context, ar *model.Article) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.ChangeArticleById")
+	defer endSpan(span, &err)
+
+	if err := ar.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	if err := a.store.db.QueryRowContext(ctx, 
+		"Update articles set article_header=$1, article_text=$2, version=version+1 where id=$3 and version=$4 and deleted_at is null returning article_header, article_text, version",
+		ar.Heading,
+		ar.Text,
+		ar.ID,
+		ar.Version,
 	).Scan(
-		&ar.ID,
 		&ar.Heading,
 		&ar.Text,
-		&ar.AuthorName,
-		&ar.Date,
+		&ar.Version,
 	); err != nil {
-		return nil, err
+		if err == sql.ErrNoRows {
+			return a.missingOrConflict(ctx, ar.ID)
+		}
+
+		return err
 	}
 
-	return ar, nil
+	return nil
+}
+
+// missingOrConflict tells apart why a versioned write matched no rows.
+func (a *ArticleRepository) missingOrConflict(ctx context.Context, id int) error {
+	var exists bool
+
+	if err := a.store.db.QueryRowContext(ctx, 
+		"select exists(select 1 from articles where id=$1 and deleted_at is null)",
+		id,
+	).Scan(&exists); err != nil {
+		return err
+	}
+
+	if exists {
+		return store.ErrEditConflict
+	}
+
+	return store.ErrRecordNotFound
 }
 
-func (a *ArticleRepository) ShowAllArticles() ([]*model.Article, error) {
+// List returns one page of articles matching f, ordered by the requested sort
+// key and then by id so that every position is unique. When more articles
+// follow, the returned cursor points at the last one on the page.
+func (a *ArticleRepository) List(ctx context.Context, f *model.ArticleFilter) (_ []*model.Article, _ *model.ArticleCursor, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.List")
+	defer endSpan(span, &err)
+
+	key, desc, err := f.SortKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit := f.PageSize()
+
+	column, cast := "a.creating_date", "::date"
+	if key == model.SortHeading {
+		column, cast = "a.article_header", ""
+	}
+
+	where := []string{"a.deleted_at is null"}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.AuthorID != 0 {
+		where = append(where, "a.author_id = "+arg(f.AuthorID))
+	}
+
+	if f.NotebookID != 0 {
+		where = append(where, "a.notebook_id = "+arg(f.NotebookID))
+	}
+
+	if !f.From.IsZero() {
+		where = append(where, "a.creating_date >= "+arg(f.From)+"::date")
+	}
+
+	if !f.To.IsZero() {
+		where = append(where, "a.creating_date <= "+arg(f.To)+"::date")
+	}
+
+	if f.Status != "" {
+		where = append(where, "a.status = "+arg(f.Status))
+	}
+
+	if f.All {
+		where = append(where, "(a.visibility <> 'private' or a.author_id = "+arg(f.ViewerID)+")")
+	} else {
+		where = append(where, "((a.status = 'published' and a.visibility = 'public') or a.author_id = "+arg(f.ViewerID)+")")
+	}
+
+	if len(f.Tags) > 0 {
+		names := make([]string, len(f.Tags))
+		for i, tag := range f.Tags {
+			names[i] = arg(tag)
+		}
+
+		tagged := "select count(*) from article_tags at join tags t on t.id = at.tag_id where at.article_id = a.id and t.name in (" + strings.Join(names, ", ") + ")"
+		if f.TagMode == model.TagModeAny {
+			where = append(where, "("+tagged+") > 0")
+		} else {
+			where = append(where, "("+tagged+") = "+arg(len(f.Tags)))
+		}
+	}
+
+	cmp, order := ">", "asc"
+	if desc {
+		cmp, order = "<", "desc"
+	}
+
+	if f.Cursor != nil {
+		where = append(where, fmt.Sprintf("(%s, a.id) %s (%s%s, %s)", column, cmp, arg(f.Cursor.Key), cast, arg(f.Cursor.ID)))
+	}
+
+	query := "select "+articleColumns+" from articles a left join users u on u.id=a.author_id where "+strings.Join(where, " and ")
+
+	query += fmt.Sprintf(" order by %s %s, a.id %s limit %s", column, order, order, arg(limit + 1))
+
+	rows, err := a.store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
 	ars := make([]*model.Article, 0)
+	for rows.Next() {
+		ar := &model.Article{}
+		if err := rows.Scan(articleFields(ar)...); err != nil {
+			return nil, nil, err
+		}
+
+		ars = append(ars, ar)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *model.ArticleCursor
+	if len(ars) > limit {
+		ars = ars[:limit]
+		next = model.NewArticleCursor(f.Sort, ars[len(ars) - 1])
+	}
+
+	if err := a.loadTags(ctx, ars); err != nil {
+		return nil, nil, err
+	}
+
+	return ars, next, nil
+}
 
-	rows, err := a.store.db.Query(
-	"select a.id, a.article_header, a.article_text, u.name, a.creating_date from articles a left join users u on u.id=a.author_id")
+// loadTags fills in the tag names of the given articles with a single query.
+func (a *ArticleRepository) loadTags(ctx context.Context, ars []*model.Article) error {
+	if len(ars) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*model.Article, len(ars))
+	ids := make([]string, 0, len(ars))
+	args := make([]interface{}, 0, len(ars))
+	for _, ar := range ars {
+		byID[ar.ID] = ar
+		args = append(args, ar.ID)
+		ids = append(ids, fmt.Sprintf("$%d", len(args)))
+	}
+
+	rows, err := a.store.db.QueryContext(ctx, 
+		"select at.article_id, t.name from article_tags at join tags t on t.id = at.tag_id where at.article_id in ("+strings.Join(ids, ", ")+") order by t.name",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+
+		byID[id].Tags = append(byID[id].Tags, name)
+	}
+
+	return rows.Err()
+}
+
+// MoveToNotebook puts the article into ar.NotebookID if it is still at
+// ar.Version.
+func (a *ArticleRepository) MoveToNotebook(ctx context.Context, ar *model.Article) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.MoveToNotebook")
+	defer endSpan(span, &err)
+
+	if err := a.store.db.QueryRowContext(ctx, 
+		"UPDATE articles SET notebook_id=$1, version=version+1 WHERE id=$2 and version=$3 and deleted_at is null returning version",
+		ar.NotebookID,
+		ar.ID,
+		ar.Version,
+	).Scan(
+		&ar.Version,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return a.missingOrConflict(ctx, ar.ID)
+		}
+
+		return storeError(err)
+	}
+
+	return nil
+}
+
+// MoveAllToNotebook moves the articles of one notebook into another. Articles
+// in the trash stay where they are.
+func (a *ArticleRepository) MoveAllToNotebook(ctx context.Context, fromID int, toID int) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.MoveAllToNotebook")
+	defer endSpan(span, &err)
+
+	_, err = a.store.db.ExecContext(ctx, 
+		"UPDATE articles SET notebook_id=$1, version=version+1 WHERE notebook_id=$2 and deleted_at is null",
+		toID,
+		fromID,
+	)
+
+	return storeError(err)
+}
+
+// MoveTrashToNotebook moves the trashed articles of one notebook into
+// another, so that they outlive the notebook until they are purged.
+func (a *ArticleRepository) MoveTrashToNotebook(ctx context.Context, fromID int, toID int) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.MoveTrashToNotebook")
+	defer endSpan(span, &err)
+
+	_, err = a.store.db.ExecContext(ctx, 
+		"UPDATE articles SET notebook_id=$1, version=version+1 WHERE notebook_id=$2 and deleted_at is not null",
+		toID,
+		fromID,
+	)
+
+	return storeError(err)
+}
+
+// TrashAllInNotebook moves every article of the notebook to the trash.
+func (a *ArticleRepository) TrashAllInNotebook(ctx context.Context, notebookID int) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.TrashAllInNotebook")
+	defer endSpan(span, &err)
+
+	_, err = a.store.db.ExecContext(ctx, 
+		"UPDATE articles SET deleted_at=now(), version=version+1 WHERE notebook_id=$1 and deleted_at is null",
+		notebookID,
+	)
+
+	return err
+}
+
+// UpdateStatus writes the lifecycle fields of the article if it is still at
+// ar.Version.
+func (a *ArticleRepository) UpdateStatus(ctx context.Context, ar *model.Article) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.UpdateStatus")
+	defer endSpan(span, &err)
+
+	if err := a.store.db.QueryRowContext(ctx, 
+		"UPDATE articles SET status=$1, visibility=$2, publish_at=$3, published_at=$4, version=version+1 WHERE id=$5 and version=$6 and deleted_at is null returning version",
+		ar.Status,
+		ar.Visibility,
+		ar.PublishAt,
+		ar.PublishedAt,
+		ar.ID,
+		ar.Version,
+	).Scan(
+		&ar.Version,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return a.missingOrConflict(ctx, ar.ID)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// PublishDue publishes the drafts whose scheduled time is not after now and
+// returns how many there were.
+func (a *ArticleRepository) PublishDue(ctx context.Context, now time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.PublishDue")
+	defer endSpan(span, &err)
+
+	res, err := a.store.db.ExecContext(ctx, 
+		"UPDATE articles SET status='published', published_at=publish_at, publish_at=null, version=version+1 WHERE status='draft' and publish_at <= $1 and deleted_at is null",
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+// ListTrash returns the trashed articles of an author, most recently trashed
+// first.
+func (a *ArticleRepository) ListTrash(ctx context.Context, authorID int) (_ []*model.Article, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.ListTrash")
+	defer endSpan(span, &err)
+
+	rows, err := a.store.db.QueryContext(ctx, 
+		"select "+articleColumns+" from articles a left join users u on u.id=a.author_id where a.author_id=$1 and a.deleted_at is not null order by a.deleted_at desc, a.id desc",
+		authorID,
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
+	ars := make([]*model.Article, 0)
 	for rows.Next() {
 		ar := &model.Article{}
-		if err := rows.Scan(
-			&ar.ID,
-			&ar.Heading,
-			&ar.Text,
-			&ar.AuthorName,
-			&ar.Date,
-		); err != nil {
+		if err := rows.Scan(articleFields(ar)...); err != nil {
 			return nil, err
 		}
 
 		ars = append(ars, ar)
 	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := a.loadTags(ctx, ars); err != nil {
+		return nil, err
+	}
+
 	return ars, nil
 }
 
-func (a *ArticleRepository) DeleteArticle(id int) (string, error) {
-	var articleHeader string
+// FindTrashed returns an article that is in the trash.
+func (a *ArticleRepository) FindTrashed(ctx context.Context, id int) (_ *model.Article, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.FindTrashed")
+	defer endSpan(span, &err)
+
+	ar := &model.Article{}
 
-	if err := a.store.db.QueryRow(
-		"DELETE FROM articles where id=$1 returning article_header",
+	if err := a.store.db.QueryRowContext(ctx, 
+		"select "+articleColumns+" from articles a left join users u on u.id=a.author_id where a.id=$1 and a.deleted_at is not null",
 		id,
-	).Scan(
-		&articleHeader,
-	); err != nil {
-		return "", err
+	).Scan(articleFields(ar)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
 	}
 
-	return articleHeader, nil
+	if err := a.loadTags(ctx, []*model.Article{ar}); err != nil {
+		return nil, err
+	}
+
+	return ar, nil
 }
-func (a *ArticleRepository) ChangeArticleById(ar *model.Article) error {
-	return a.store.db.QueryRow(
-		"Update articles set article_header=$1, article_text=$2 where id=$3 returning article_header, article_text",
-		ar.Heading,
-		ar.Text,
+
+// Restore takes the article back out of the trash.
+func (a *ArticleRepository) Restore(ctx context.Context, ar *model.Article) (err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.Restore")
+	defer endSpan(span, &err)
+
+	if err := a.store.db.QueryRowContext(ctx, 
+		"UPDATE articles SET deleted_at=null, version=version+1 WHERE id=$1 and deleted_at is not null returning version",
 		ar.ID,
 	).Scan(
-		&ar.Heading,
-		&ar.Text,
+		&ar.Version,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	ar.DeletedAt = nil
+
+	return nil
+}
+
+// EmptyTrash permanently removes all trashed articles of an author.
+func (a *ArticleRepository) EmptyTrash(ctx context.Context, authorID int) (_ int, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.EmptyTrash")
+	defer endSpan(span, &err)
+
+	return a.purge(ctx, "DELETE FROM articles WHERE author_id=$1 and deleted_at is not null", authorID)
+}
+
+// PurgeTrash permanently removes the articles that were trashed before the
+// given time.
+func (a *ArticleRepository) PurgeTrash(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.PurgeTrash")
+	defer endSpan(span, &err)
+
+	return a.purge(ctx, "DELETE FROM articles WHERE deleted_at < $1", before)
+}
+
+func (a *ArticleRepository) purge(ctx context.Context, query string, arg interface{}) (int, error) {
+	res, err := a.store.db.ExecContext(ctx, query, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+// escapeHTML is the SQL that escapes column like html.EscapeString. Headlines
+// are made from the escaped text, so that the <mark> tags are the only markup
+// in them.
+func escapeHTML(column string) string {
+	return "replace(replace(replace(replace(replace(" + column + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+}
+
+// Search runs a full-text query against the generated search_vector column.
+// Results are ordered by ts_rank_cd, with newer articles first on ties.
+func (a *ArticleRepository) Search(ctx context.Context, f *model.SearchFilter) (_ []*model.SearchResult, err error) {
+	ctx, span := startSpan(ctx, "ArticleRepository.Search")
+	defer endSpan(span, &err)
+
+	q, err := search.Parse(f.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to sql.NullTime
+	if !f.From.IsZero() {
+		from = sql.NullTime{Time: f.From, Valid: true}
+	}
+
+	if !f.To.IsZero() {
+		to = sql.NullTime{Time: f.To, Valid: true}
+	}
+
+	rows, err := a.store.db.QueryContext(ctx, 
+		`select `+articleColumns+`,
+			ts_rank_cd(a.search_vector, q) as rank,
+			ts_headline('english', `+escapeHTML("a.article_header")+`, q, $6),
+			ts_headline('english', `+escapeHTML("a.article_text")+`, q, $7)
+		from articles a left join users u on u.id=a.author_id, to_tsquery('english', $1) q
+		where a.search_vector @@ q
+			and a.deleted_at is null
+			and ($2::int = 0 or a.author_id = $2)
+			and ($3::date is null or a.creating_date >= $3)
+			and ($4::date is null or a.creating_date <= $4)
+			and (($8::bool and a.visibility <> 'private') or (a.status = 'published' and a.visibility = 'public') or a.author_id = $9)
+		order by rank desc, a.id desc
+		limit nullif($5::int, 0)`,
+		q.TSQuery(),
+		f.AuthorID,
+		from,
+		to,
+		f.Limit,
+		"StartSel=<mark>, StopSel=</mark>, HighlightAll=true",
+		"StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10",
+		f.All,
+		f.ViewerID,
 	)
-}
\ No newline at end of file
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*model.SearchResult, 0)
+	for rows.Next() {
+		res := &model.SearchResult{Article: &model.Article{}}
+		if err := rows.Scan(append(
+			articleFields(res.Article),
+			&res.Rank,
+			&res.HeadingHighlight,
+			&res.Snippet,
+		)...); err != nil {
+			return nil, err
+		}
+
+		results = append(results, res)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,26 @@
+package sqlstore
+
+import (
+	"errors"
+	"github.com/lib/pq"
+	"rest_api/internal/app/store"
+)
+
+// storeError translates constraint violations reported by PostgreSQL into
+// the store's errors, so that driver messages do not reach callers. Other
+// errors are returned unchanged.
+func storeError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code {
+	case "23505":
+		return store.ErrAlreadyExists
+	case "23503":
+		return store.ErrInvalidReference
+	}
+
+	return err
+}
@@ -0,0 +1,182 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+)
+
+type NotebookRepository struct {
+	store *Store
+}
+
+func (r *NotebookRepository) Create(ctx context.Context, n *model.Notebook) (err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Create")
+	defer endSpan(span, &err)
+
+	if err := n.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	err = r.store.db.QueryRowContext(ctx, 
+		"INSERT INTO notebooks (name, owner_id) VALUES ($1, $2) RETURNING id, is_default, created_at",
+		n.Name,
+		n.OwnerID,
+	).Scan(
+		&n.ID,
+		&n.IsDefault,
+		&n.CreatedAt,
+	)
+
+	return storeError(err)
+}
+
+func (r *NotebookRepository) Find(ctx context.Context, id int) (_ *model.Notebook, err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Find")
+	defer endSpan(span, &err)
+
+	n := &model.Notebook{}
+
+	if err := r.store.db.QueryRowContext(ctx, 
+		"SELECT id, name, owner_id, is_default, created_at FROM notebooks WHERE id = $1",
+		id,
+	).Scan(
+		&n.ID,
+		&n.Name,
+		&n.OwnerID,
+		&n.IsDefault,
+		&n.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (r *NotebookRepository) FindByOwner(ctx context.Context, ownerID int) (_ []*model.Notebook, err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.FindByOwner")
+	defer endSpan(span, &err)
+
+	rows, err := r.store.db.QueryContext(ctx, 
+		"SELECT id, name, owner_id, is_default, created_at FROM notebooks WHERE owner_id = $1 ORDER BY id",
+		ownerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ns := make([]*model.Notebook, 0)
+	for rows.Next() {
+		n := &model.Notebook{}
+		if err := rows.Scan(
+			&n.ID,
+			&n.Name,
+			&n.OwnerID,
+			&n.IsDefault,
+			&n.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		ns = append(ns, n)
+	}
+
+	return ns, rows.Err()
+}
+
+// FindOrCreateDefault returns the owner's default notebook, creating it the
+// first time it is needed.
+func (r *NotebookRepository) FindOrCreateDefault(ctx context.Context, ownerID int) (_ *model.Notebook, err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.FindOrCreateDefault")
+	defer endSpan(span, &err)
+
+	n := &model.Notebook{}
+
+	err = r.store.db.QueryRowContext(ctx, 
+		`WITH ins AS (
+			INSERT INTO notebooks (name, owner_id, is_default) VALUES ($1, $2, true)
+			ON CONFLICT (owner_id) WHERE is_default DO NOTHING
+			RETURNING id, name, owner_id, is_default, created_at
+		)
+		SELECT id, name, owner_id, is_default, created_at FROM ins
+		UNION ALL
+		SELECT id, name, owner_id, is_default, created_at FROM notebooks WHERE owner_id = $2 AND is_default
+		LIMIT 1`,
+		model.DefaultNotebookName,
+		ownerID,
+	).Scan(
+		&n.ID,
+		&n.Name,
+		&n.OwnerID,
+		&n.IsDefault,
+		&n.CreatedAt,
+	)
+
+	// When another transaction creates the notebook at the same time, the
+	// insert waits for it and does nothing, but the select still reads the
+	// statement's snapshot, from before that notebook was committed. A
+	// statement of its own sees it.
+	if err == sql.ErrNoRows {
+		err = r.store.db.QueryRowContext(ctx, 
+			"SELECT id, name, owner_id, is_default, created_at FROM notebooks WHERE owner_id = $1 AND is_default",
+			ownerID,
+		).Scan(
+			&n.ID,
+			&n.Name,
+			&n.OwnerID,
+			&n.IsDefault,
+			&n.CreatedAt,
+		)
+	}
+
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return n, nil
+}
+
+func (r *NotebookRepository) Rename(ctx context.Context, id int, name string) (err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Rename")
+	defer endSpan(span, &err)
+
+	n := &model.Notebook{Name: name}
+	if err := n.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	return r.exec(ctx, "UPDATE notebooks SET name = $1 WHERE id = $2", name, id)
+}
+
+// Delete removes the notebook together with every article in it. Callers that
+// want to keep the articles move them elsewhere first.
+func (r *NotebookRepository) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "NotebookRepository.Delete")
+	defer endSpan(span, &err)
+
+	return r.exec(ctx, "DELETE FROM notebooks WHERE id = $1", id)
+}
+
+func (r *NotebookRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+	res, err := r.store.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -1,20 +1,89 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
+	"rest_api/internal/app/logging"
 	"rest_api/internal/app/store"
 )
 
+// querier is implemented by both *sql.DB and *sql.Tx, so repositories run the
+// same queries inside and outside of a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 type Store struct {
-	db *sql.DB
+	db querier
+	conn *sql.DB
 	userRepository *UserRepository
 	articleRepository *ArticleRepository
+	refreshTokenRepository *RefreshTokenRepository
+	notebookRepository *NotebookRepository
+	revisionRepository *RevisionRepository
+	tagRepository *TagRepository
 }
 
 func New(db *sql.DB) *Store {
 	return &Store {
 		db: db,
+		conn: db,
+	}
+}
+
+// WithTx runs fn with a store whose repositories share one transaction. The
+// transaction is committed when fn returns nil and rolled back when it fails
+// or panics. Calling WithTx on a transactional store joins its transaction.
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.transact(ctx, func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+// Ping checks that the database answers. A transactional store checks the
+// connection of its transaction.
+func (s *Store) Ping(ctx context.Context) error {
+	if s.conn != nil {
+		return s.conn.PingContext(ctx)
 	}
+
+	_, err := s.db.ExecContext(ctx, "SELECT 1")
+
+	return err
+}
+
+func (s *Store) transact(ctx context.Context, fn func(*Store) error) (err error) {
+	if s.conn == nil {
+		return fn(s)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("rolling back transaction", "error", rbErr, "cause", err)
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return fn(&Store {
+		db: tx,
+	})
 }
 
 func (s *Store) User() store.UserRepository {
@@ -36,3 +105,43 @@ func (s *Store) Article() store.ArticleRepository {
 
 	return s.articleRepository
 }
+
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository == nil {
+		s.refreshTokenRepository = &RefreshTokenRepository{
+			s,
+		}
+	}
+
+	return s.refreshTokenRepository
+}
+
+func (s *Store) Notebook() store.NotebookRepository {
+	if s.notebookRepository == nil {
+		s.notebookRepository = &NotebookRepository{
+			s,
+		}
+	}
+
+	return s.notebookRepository
+}
+
+func (s *Store) Revision() store.RevisionRepository {
+	if s.revisionRepository == nil {
+		s.revisionRepository = &RevisionRepository{
+			s,
+		}
+	}
+
+	return s.revisionRepository
+}
+
+func (s *Store) Tag() store.TagRepository {
+	if s.tagRepository == nil {
+		s.tagRepository = &TagRepository{
+			s,
+		}
+	}
+
+	return s.tagRepository
+}
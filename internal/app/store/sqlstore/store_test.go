@@ -2,6 +2,9 @@ package sqlstore_test
 
 import (
 	"os"
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/sqlstore"
+	"rest_api/internal/app/store/storetest"
 	"testing"
 )
 
@@ -17,3 +20,15 @@ func TestMain(m *testing.M) {
 
 	os.Exit(m.Run())
 }
+
+func TestStore(t *testing.T) {
+	storetest.RunAll(t, func(t *testing.T) store.Store {
+		db, teardown := sqlstore.TestDB(t, databaseString)
+		t.Cleanup(func() {
+			teardown("users", "notebooks", "articles", "article_revisions", "tags", "refresh_tokens")
+			db.Close()
+		})
+
+		return sqlstore.New(db)
+	})
+}
@@ -0,0 +1,41 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"rest_api/internal/app/store"
+)
+
+const instrumentationName = "rest_api/internal/app/store/sqlstore"
+
+// startSpan starts the span of a repository call as a child of the span in
+// ctx. name is the statement the call runs, such as "ArticleRepository.Find".
+// The tracer is looked up on every call, so that the provider installed with
+// otel.SetTracerProvider is used however early the store was built.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(name),
+		),
+	)
+}
+
+// endSpan ends span with the error the call returned. A record that does not
+// exist is an answer rather than a failure and leaves the status unset.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		var notFound *store.NotFoundError
+		if !errors.As(*err, &notFound) {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+
+	span.End()
+}
@@ -0,0 +1,65 @@
+package sqlstore_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/sqlstore"
+	"testing"
+)
+
+func TestStore_Tracing(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseString)
+	defer db.Close()
+	defer teardown("users", "notebooks", "articles")
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	s := sqlstore.New(db)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+
+	u := model.TestUser(t)
+	require.NoError(t, s.User().Create(ctx, u))
+
+	a := model.TestArticle(t, u.ID)
+	require.NoError(t, s.Article().CreateArticle(ctx, a))
+
+	_, err := s.Article().Find(ctx, a.ID + 1)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+
+	parent.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	for _, name := range []string{"UserRepository.Create", "ArticleRepository.CreateArticle", "ArticleRepository.Find"} {
+		span := spans[name]
+		if assert.NotNil(t, span, name) {
+			assert.Equal(t, parent.SpanContext().TraceID(), span.SpanContext().TraceID())
+			assert.Contains(t, span.Attributes(), attribute.String("db.operation", name))
+		}
+	}
+
+	// The default notebook is made inside CreateArticle.
+	notebook := spans["NotebookRepository.FindOrCreateDefault"]
+	if assert.NotNil(t, notebook) {
+		assert.Equal(t, spans["ArticleRepository.CreateArticle"].SpanContext().SpanID(), notebook.Parent().SpanID())
+	}
+
+	assert.Equal(t, codes.Unset, spans["ArticleRepository.Find"].Status().Code)
+}
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
 	"rest_api/internal/app/model"
 	"rest_api/internal/app/store"
@@ -10,10 +11,13 @@ type UserRepository struct {
 	store *Store
 }
 
-func (ur *UserRepository) Create(u *model.User) error {
-	err := u.Validate()
+func (ur *UserRepository) Create(ctx context.Context, u *model.User) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Create")
+	defer endSpan(span, &err)
+
+	err = u.Validate()
 	if err != nil {
-		return err
+		return store.NewValidationError(err)
 	}
 
 	err = u.BeforeCreate()
@@ -21,24 +25,58 @@ func (ur *UserRepository) Create(u *model.User) error {
 		return err
 	}
 
-	return ur.store.db.QueryRow(
-		"INSERT INTO users (name, email, encrypted_password) VALUES ($1, $2, $3) RETURNING id",
+	err = ur.store.db.QueryRowContext(ctx, 
+		"INSERT INTO users (name, email, encrypted_password, role) VALUES ($1, $2, $3, $4) RETURNING id",
 		u.Name,
 		u.Email,
 		u.EncryptedPassword,
+		u.Role,
 	).Scan(&u.ID)
+
+	return storeError(err)
+}
+
+func (ur *UserRepository) Find(ctx context.Context, id int) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Find")
+	defer endSpan(span, &err)
+
+	u := model.User{}
+
+	if err := ur.store.db.QueryRowContext(ctx, 
+		"SELECT id, name, email, encrypted_password, role FROM users where id = $1",
+		id,
+	).Scan(
+		&u.ID,
+		&u.Name,
+		&u.Email,
+		&u.EncryptedPassword,
+		&u.Role,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return &u, nil
 }
 
-func (ur *UserRepository) FindByEmail(email string) (*model.User, error) {
+func (ur *UserRepository) FindByEmail(ctx context.Context, email string) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByEmail")
+	defer endSpan(span, &err)
+
 	u := model.User{}
 
-	if err := ur.store.db.QueryRow(
-		"SELECT id, email, encrypted_password FROM users where email = $1",
+	if err := ur.store.db.QueryRowContext(ctx, 
+		"SELECT id, name, email, encrypted_password, role FROM users where email = $1",
 		email,
 	).Scan(
 		&u.ID,
+		&u.Name,
 		&u.Email,
 		&u.EncryptedPassword,
+		&u.Role,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrRecordNotFound
@@ -1,6 +1,14 @@
 package store
 
+import "context"
+
 type Store interface {
 	User() UserRepository
 	Article() ArticleRepository
+	RefreshToken() RefreshTokenRepository
+	Notebook() NotebookRepository
+	Revision() RevisionRepository
+	Tag() TagRepository
+	WithTx(ctx context.Context, fn func(Store) error) error
+	Ping(ctx context.Context) error
 }
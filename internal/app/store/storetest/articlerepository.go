@@ -0,0 +1,636 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RunArticleRepositoryTests checks the behavior every
+// store.ArticleRepository has to share.
+func RunArticleRepositoryTests(t *testing.T, newStore Factory) {
+	t.Run("CreateArticle", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		err := s.Article().CreateArticle(context.Background(), a)
+		assert.NoError(t, err)
+		assert.NotNil(t, a)
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+
+		a1, err := s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, u.ID, a1.AuthorID)
+		assert.Equal(t, u.Name, a1.AuthorName)
+
+		_, err = s.Article().Find(context.Background(), a.ID + 1)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+
+	t.Run("FindByHeading", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+
+		a1, err := s.Article().FindByHeading(context.Background(), a.Heading, model.Audience{ViewerID: u.ID})
+		assert.NoError(t, err)
+		assert.NotNil(t, a1)
+
+		draft := &model.Article{Heading: "Plan", Text: "unfinished notes", AuthorID: u.ID}
+		s.Article().CreateArticle(context.Background(), draft)
+
+		_, err = s.Article().FindByHeading(context.Background(), draft.Heading, model.Audience{ViewerID: u.ID + 1})
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		// An editor or admin reads the drafts of others.
+		a1, err = s.Article().FindByHeading(context.Background(), draft.Heading, model.Audience{ViewerID: u.ID + 1, All: true})
+		assert.NoError(t, err)
+		if assert.NotNil(t, a1) {
+			assert.Equal(t, draft.ID, a1.ID)
+		}
+	})
+
+	t.Run("FindByHeadingSkipsUnreadable", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		private := &model.Article{Heading: "Diary", Text: "secret notes", AuthorID: u.ID, Status: model.StatusPublished, Visibility: model.VisibilityPrivate}
+		s.Article().CreateArticle(context.Background(), private)
+		public := &model.Article{Heading: "Diary", Text: "public notes", AuthorID: u.ID, Status: model.StatusPublished}
+		s.Article().CreateArticle(context.Background(), public)
+
+		for _, aud := range []model.Audience{{}, {ViewerID: u.ID + 1}, {ViewerID: u.ID + 1, All: true}} {
+			a, err := s.Article().FindByHeading(context.Background(), "Diary", aud)
+			assert.NoError(t, err)
+			if assert.NotNil(t, a) {
+				assert.Equal(t, public.ID, a.ID)
+			}
+		}
+
+		// The author reads both and gets the older one.
+		a, err := s.Article().FindByHeading(context.Background(), "Diary", model.Audience{ViewerID: u.ID})
+		assert.NoError(t, err)
+		if assert.NotNil(t, a) {
+			assert.Equal(t, private.ID, a.ID)
+		}
+	})
+
+	t.Run("DeleteArticle", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		err := s.Article().CreateArticle(context.Background(), a)
+		assert.NoError(t, err)
+
+		header, err := s.Article().DeleteArticle(context.Background(), a.ID, a.Version)
+		assert.NoError(t, err)
+		assert.Equal(t, a.Heading, header)
+	})
+
+	t.Run("ChangeArticleById", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+
+		another := & model.Article{
+			Heading: "Another Header",
+			Text: "Another text",
+			ID: a.ID,
+			Version: a.Version,
+		}
+		err := s.Article().ChangeArticleById(context.Background(), another)
+		assert.NoError(t, err)
+		assert.Equal(t, a.Version+1, another.Version)
+
+		a, err = s.Article().FindByHeading(context.Background(), "Another Header", model.Audience{ViewerID: u.ID})
+		assert.Equal(t, "Another Header", a.Heading)
+		assert.Equal(t, "Another text", a.Text)
+	})
+
+	t.Run("EditConflict", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+		assert.Equal(t, 1, a.Version)
+
+		first := &model.Article{ID: a.ID, Heading: "First", Text: "First", Version: a.Version}
+		assert.NoError(t, s.Article().ChangeArticleById(context.Background(), first))
+
+		second := &model.Article{ID: a.ID, Heading: "Second", Text: "Second", Version: a.Version}
+		assert.EqualError(t, s.Article().ChangeArticleById(context.Background(), second), store.ErrEditConflict.Error())
+
+		_, err := s.Article().DeleteArticle(context.Background(), a.ID, a.Version)
+		assert.EqualError(t, err, store.ErrEditConflict.Error())
+
+		_, err = s.Article().DeleteArticle(context.Background(), a.ID+1, 1)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		s.Article().CreateArticle(context.Background(), &model.Article{Heading: "REST API notes", Text: "How to design a rest api", AuthorID: u.ID, Status: model.StatusPublished})
+		s.Article().CreateArticle(context.Background(), &model.Article{Heading: "Shopping", Text: "Milk and notebooks", AuthorID: u.ID, Status: model.StatusPublished})
+
+		results, err := s.Article().Search(context.Background(), &model.SearchFilter{Query: `"rest api"`})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "REST API notes", results[0].Heading)
+		assert.Contains(t, results[0].Snippet, "<mark>")
+
+		results, err = s.Article().Search(context.Background(), &model.SearchFilter{Query: "note*", Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+
+		results, err = s.Article().Search(context.Background(), &model.SearchFilter{Query: "rest", AuthorID: u.ID + 1})
+		assert.NoError(t, err)
+		assert.Len(t, results, 0)
+
+		results, err = s.Article().Search(context.Background(), &model.SearchFilter{Query: "rest", To: time.Now().AddDate(0, 0, -1)})
+		assert.NoError(t, err)
+		assert.Len(t, results, 0)
+	})
+
+	t.Run("SearchEscapesHighlights", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		s.Article().CreateArticle(context.Background(), &model.Article{Heading: "<i>Recipes</i>", Text: `Pancakes <img src=x onerror="alert(1)"> recipe`, AuthorID: u.ID, Status: model.StatusPublished})
+
+		results, err := s.Article().Search(context.Background(), &model.SearchFilter{Query: "recipe*"})
+		assert.NoError(t, err)
+		if assert.Len(t, results, 1) {
+			assert.Contains(t, results[0].HeadingHighlight, "&lt;i&gt;")
+			assert.Contains(t, results[0].Snippet, "<mark>")
+
+			// The marks are the only markup left.
+			marks := strings.NewReplacer("<mark>", "", "</mark>", "")
+			for _, highlight := range []string{results[0].HeadingHighlight, results[0].Snippet} {
+				assert.NotContains(t, marks.Replace(highlight), "<")
+				assert.NotContains(t, marks.Replace(highlight), ">")
+				assert.NotContains(t, highlight, `"`)
+			}
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		for _, heading := range []string{"delta", "alpha", "charlie", "bravo"} {
+			s.Article().CreateArticle(context.Background(), &model.Article{Heading: heading, Text: "text", AuthorID: u.ID, Status: model.StatusPublished})
+		}
+
+		f := &model.ArticleFilter{Sort: model.SortHeading, Limit: 3}
+		ars, next, err := s.Article().List(context.Background(), f)
+		assert.NoError(t, err)
+		assert.Len(t, ars, 3)
+		assert.Equal(t, "alpha", ars[0].Heading)
+		assert.NotNil(t, next)
+
+		f.Cursor = next
+		ars, next, err = s.Article().List(context.Background(), f)
+		assert.NoError(t, err)
+		assert.Len(t, ars, 1)
+		assert.Equal(t, "delta", ars[0].Heading)
+		assert.Nil(t, next)
+
+		ars, _, err = s.Article().List(context.Background(), &model.ArticleFilter{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, "bravo", ars[0].Heading)
+
+		ars, _, err = s.Article().List(context.Background(), &model.ArticleFilter{AuthorID: u.ID + 1})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 0)
+
+		_, _, err = s.Article().List(context.Background(), &model.ArticleFilter{Sort: "text"})
+		assert.Equal(t, model.ErrInvalidSort, err)
+	})
+
+	t.Run("PrivateArticles", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		s.Article().CreateArticle(context.Background(), &model.Article{Heading: "Diary", Text: "secret notes", AuthorID: u.ID, Status: model.StatusPublished, Visibility: model.VisibilityPrivate})
+		s.Article().CreateArticle(context.Background(), &model.Article{Heading: "Draft", Text: "unfinished notes", AuthorID: u.ID})
+
+		// An editor or admin sees the drafts of others, but not their
+		// private articles.
+		ars, _, err := s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{ViewerID: u.ID + 1, All: true}})
+		assert.NoError(t, err)
+		if assert.Len(t, ars, 1) {
+			assert.Equal(t, "Draft", ars[0].Heading)
+		}
+
+		results, err := s.Article().Search(context.Background(), &model.SearchFilter{Query: "notes", Audience: model.Audience{ViewerID: u.ID + 1, All: true}})
+		assert.NoError(t, err)
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "Draft", results[0].Heading)
+		}
+
+		ars, _, err = s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{ViewerID: u.ID, All: true}})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 2)
+
+		results, err = s.Article().Search(context.Background(), &model.SearchFilter{Query: "notes", Audience: model.Audience{ViewerID: u.ID}})
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := &model.Article{Heading: "Draft", Text: "text", AuthorID: u.ID}
+		assert.NoError(t, s.Article().CreateArticle(context.Background(), a))
+		assert.Equal(t, model.StatusDraft, a.Status)
+
+		ars, _, err := s.Article().List(context.Background(), &model.ArticleFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 0)
+
+		ars, _, err = s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{ViewerID: u.ID}, Status: model.StatusDraft})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 1)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		assert.NoError(t, a.Publish(now.Add(time.Hour), now))
+		assert.NoError(t, s.Article().UpdateStatus(context.Background(), a))
+		assert.Equal(t, 2, a.Version)
+
+		n, err := s.Article().PublishDue(context.Background(), now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		n, err = s.Article().PublishDue(context.Background(), now.Add(2 * time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		a1, err := s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusPublished, a1.Status)
+		assert.Nil(t, a1.PublishAt)
+		assert.NotNil(t, a1.PublishedAt)
+
+		results, err := s.Article().Search(context.Background(), &model.SearchFilter{Query: "draft"})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+
+		a.Version = 2
+		assert.EqualError(t, s.Article().UpdateStatus(context.Background(), a), store.ErrEditConflict.Error())
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+
+		_, err := s.Article().DeleteArticle(context.Background(), a.ID, a.Version)
+		assert.NoError(t, err)
+
+		_, err = s.Article().Find(context.Background(), a.ID)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		ars, err := s.Article().ListTrash(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Len(t, ars, 1)
+		assert.NotNil(t, ars[0].DeletedAt)
+
+		trashed, err := s.Article().FindTrashed(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.NoError(t, s.Article().Restore(context.Background(), trashed))
+		assert.Equal(t, 3, trashed.Version)
+
+		_, err = s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+
+		_, err = s.Article().DeleteArticle(context.Background(), a.ID, trashed.Version)
+		assert.NoError(t, err)
+
+		n, err := s.Article().PurgeTrash(context.Background(), time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		n, err = s.Article().PurgeTrash(context.Background(), time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		_, err = s.Article().FindTrashed(context.Background(), a.ID)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+
+	t.Run("MoveToNotebook", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+		assert.NotZero(t, a.NotebookID)
+
+		n := &model.Notebook{Name: "Work", OwnerID: u.ID}
+		s.Notebook().Create(context.Background(), n)
+
+		defaultID := a.NotebookID
+		a.NotebookID = n.ID
+		assert.NoError(t, s.Article().MoveToNotebook(context.Background(), a))
+		assert.Equal(t, 2, a.Version)
+		ars, _, err := s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{All: true}, NotebookID: n.ID})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 1)
+
+		assert.NoError(t, s.Article().MoveAllToNotebook(context.Background(), n.ID, defaultID))
+		ars, _, err = s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{All: true}, NotebookID: defaultID})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 1)
+	})
+
+	t.Run("MoveAllToNotebookSkipsTrash", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		n := &model.Notebook{Name: "Work", OwnerID: u.ID}
+		s.Notebook().Create(context.Background(), n)
+
+		live := &model.Article{Heading: "Live", Text: "text", AuthorID: u.ID, NotebookID: n.ID}
+		trashed := &model.Article{Heading: "Trashed", Text: "text", AuthorID: u.ID, NotebookID: n.ID}
+		s.Article().CreateArticle(context.Background(), live)
+		s.Article().CreateArticle(context.Background(), trashed)
+		_, err := s.Article().DeleteArticle(context.Background(), trashed.ID, trashed.Version)
+		assert.NoError(t, err)
+
+		def, err := s.Notebook().FindOrCreateDefault(context.Background(), u.ID)
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Article().MoveAllToNotebook(context.Background(), n.ID, def.ID))
+		a, err := s.Article().FindTrashed(context.Background(), trashed.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, n.ID, a.NotebookID)
+		assert.Equal(t, trashed.Version + 1, a.Version)
+
+		assert.NoError(t, s.Article().MoveTrashToNotebook(context.Background(), n.ID, def.ID))
+		a, err = s.Article().FindTrashed(context.Background(), trashed.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, def.ID, a.NotebookID)
+
+		a, err = s.Article().Find(context.Background(), live.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, def.ID, a.NotebookID)
+	})
+
+	t.Run("TrashAllInNotebook", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		n := &model.Notebook{Name: "Work", OwnerID: u.ID}
+		s.Notebook().Create(context.Background(), n)
+
+		inside := &model.Article{Heading: "Inside", Text: "text", AuthorID: u.ID, NotebookID: n.ID}
+		outside := &model.Article{Heading: "Outside", Text: "text", AuthorID: u.ID}
+		s.Article().CreateArticle(context.Background(), inside)
+		s.Article().CreateArticle(context.Background(), outside)
+
+		assert.NoError(t, s.Article().TrashAllInNotebook(context.Background(), n.ID))
+
+		_, err := s.Article().Find(context.Background(), inside.ID)
+		assert.Equal(t, store.ErrRecordNotFound, err)
+		a, err := s.Article().FindTrashed(context.Background(), inside.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, a.DeletedAt)
+		assert.Equal(t, inside.Version + 1, a.Version)
+
+		_, err = s.Article().Find(context.Background(), outside.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("IDs", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a1 := &model.Article{Heading: "First", Text: "text", AuthorID: u.ID}
+		a2 := &model.Article{Heading: "First", Text: "same heading", AuthorID: u.ID}
+		assert.NoError(t, s.Article().CreateArticle(context.Background(), a1))
+		assert.NoError(t, s.Article().CreateArticle(context.Background(), a2))
+		assert.Positive(t, a1.ID)
+		assert.Greater(t, a2.ID, a1.ID)
+
+		// Ids of purged articles are not handed out again.
+		_, err := s.Article().DeleteArticle(context.Background(), a2.ID, a2.Version)
+		assert.NoError(t, err)
+		_, err = s.Article().EmptyTrash(context.Background(), u.ID)
+		assert.NoError(t, err)
+
+		a3 := &model.Article{Heading: "Third", Text: "text", AuthorID: u.ID}
+		assert.NoError(t, s.Article().CreateArticle(context.Background(), a3))
+		assert.Greater(t, a3.ID, a2.ID)
+	})
+
+	t.Run("Fields", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		assert.NoError(t, s.Article().CreateArticle(context.Background(), a))
+		assert.Equal(t, 1, a.Version)
+		assert.NotZero(t, a.NotebookID)
+
+		a1, err := s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, a.ID, a1.ID)
+		assert.Equal(t, a.Heading, a1.Heading)
+		assert.Equal(t, a.Text, a1.Text)
+		assert.Equal(t, u.ID, a1.AuthorID)
+		assert.Equal(t, u.Name, a1.AuthorName)
+		assert.Equal(t, a.NotebookID, a1.NotebookID)
+		assert.Equal(t, a.Date, a1.Date)
+		assert.Equal(t, 1, a1.Version)
+		assert.Equal(t, model.StatusPublished, a1.Status)
+		assert.Equal(t, model.VisibilityPublic, a1.Visibility)
+		assert.NotNil(t, a1.PublishedAt)
+		assert.Nil(t, a1.DeletedAt)
+		assert.Empty(t, a1.Tags)
+
+		_, err = time.Parse(time.RFC3339, a1.Date)
+		assert.NoError(t, err)
+
+		a2, err := s.Article().FindByHeading(context.Background(), a.Heading, model.Audience{})
+		assert.NoError(t, err)
+		assert.Equal(t, a1.ID, a2.ID)
+		assert.Equal(t, u.Name, a2.AuthorName)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+		missing := a.ID + 1
+
+		_, err := s.Article().Find(context.Background(), missing)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		_, err = s.Article().FindByHeading(context.Background(), "Missing heading", model.Audience{ViewerID: u.ID})
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		err = s.Article().ChangeArticleById(context.Background(), &model.Article{ID: missing, Heading: "Missing", Text: "text", Version: 1})
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		_, err = s.Article().DeleteArticle(context.Background(), missing, 1)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		err = s.Article().MoveToNotebook(context.Background(), &model.Article{ID: missing, NotebookID: a.NotebookID, Version: 1})
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		err = s.Article().UpdateStatus(context.Background(), &model.Article{ID: missing, Status: model.StatusDraft, Visibility: model.VisibilityPublic, Version: 1})
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		_, err = s.Article().FindTrashed(context.Background(), a.ID)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		err = s.Article().Restore(context.Background(), &model.Article{ID: a.ID})
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+
+	t.Run("TrashFields", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		first := &model.Article{Heading: "First", Text: "text", AuthorID: u.ID}
+		second := &model.Article{Heading: "Second", Text: "text", AuthorID: u.ID}
+		s.Article().CreateArticle(context.Background(), first)
+		s.Article().CreateArticle(context.Background(), second)
+		s.Tag().SetArticleTags(context.Background(), first.ID, u.ID, []string{"work"})
+
+		_, err := s.Article().DeleteArticle(context.Background(), first.ID, first.Version)
+		assert.NoError(t, err)
+		_, err = s.Article().DeleteArticle(context.Background(), second.ID, second.Version)
+		assert.NoError(t, err)
+
+		ars, err := s.Article().ListTrash(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Len(t, ars, 2)
+		assert.Equal(t, second.ID, ars[0].ID)
+		assert.Equal(t, []string{"work"}, ars[1].Tags)
+		assert.Equal(t, u.Name, ars[1].AuthorName)
+		assert.Equal(t, 2, ars[1].Version)
+
+		trashed, err := s.Article().FindTrashed(context.Background(), first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"work"}, trashed.Tags)
+		assert.NotNil(t, trashed.DeletedAt)
+	})
+	t.Run("References", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		assert.Equal(t, store.ErrInvalidReference, s.Article().CreateArticle(context.Background(), model.TestArticle(t, u.ID + 1)))
+
+		a := model.TestArticle(t, u.ID)
+		a.NotebookID = 1000
+		assert.Equal(t, store.ErrInvalidReference, s.Article().CreateArticle(context.Background(), a))
+
+		a = model.TestArticle(t, u.ID)
+		assert.NoError(t, s.Article().CreateArticle(context.Background(), a))
+
+		a.NotebookID = 1000
+		assert.Equal(t, store.ErrInvalidReference, s.Article().MoveToNotebook(context.Background(), a))
+
+		ars, _, err := s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{All: true}})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 1)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		a.Heading = strings.Repeat("h", model.MaxHeadingLength + 1)
+		a.Text = " "
+
+		var invalid *store.ValidationError
+		assert.True(t, errors.As(s.Article().CreateArticle(context.Background(), a), &invalid))
+		assert.Contains(t, invalid.Fields, "article_heading")
+		assert.Contains(t, invalid.Fields, "article_text")
+
+		// varchar(50) counts characters, not bytes.
+		a = model.TestArticle(t, u.ID)
+		a.Heading = strings.Repeat("ж", model.MaxHeadingLength)
+		assert.NoError(t, s.Article().CreateArticle(context.Background(), a))
+
+		changed := &model.Article{ID: a.ID, Heading: "Two\nlines", Text: "text", Version: a.Version}
+		assert.True(t, errors.As(s.Article().ChangeArticleById(context.Background(), changed), &invalid))
+		assert.Contains(t, invalid.Fields, "article_heading")
+
+		a, err := s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, a.Version)
+	})
+}
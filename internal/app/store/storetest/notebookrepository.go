@@ -0,0 +1,150 @@
+package storetest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"sync"
+	"testing"
+)
+
+// RunNotebookRepositoryTests checks the behavior every
+// store.NotebookRepository has to share.
+func RunNotebookRepositoryTests(t *testing.T, newStore Factory) {
+	t.Run("Create", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		n := &model.Notebook{Name: "Work", OwnerID: u.ID}
+		assert.NoError(t, s.Notebook().Create(context.Background(), n))
+		assert.NotZero(t, n.ID)
+		assert.False(t, n.IsDefault)
+
+		assert.Error(t, s.Notebook().Create(context.Background(), &model.Notebook{OwnerID: u.ID}))
+	})
+
+	t.Run("FindOrCreateDefault", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		n1, err := s.Notebook().FindOrCreateDefault(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.True(t, n1.IsDefault)
+
+		n2, err := s.Notebook().FindOrCreateDefault(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, n1.ID, n2.ID)
+
+		ns, err := s.Notebook().FindByOwner(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Len(t, ns, 1)
+	})
+
+	t.Run("FindOrCreateDefaultConcurrently", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		const callers = 8
+
+		// Stores set up their repositories lazily, so r is fetched up front.
+		r := s.Notebook()
+
+		ids := make(chan int, callers)
+		errs := make(chan error, callers)
+		wg := &sync.WaitGroup{}
+
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				n, err := r.FindOrCreateDefault(context.Background(), u.ID)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				ids <- n.ID
+			}()
+		}
+
+		wg.Wait()
+		close(ids)
+		close(errs)
+
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		seen := map[int]bool{}
+		for id := range ids {
+			seen[id] = true
+		}
+
+		assert.Len(t, seen, 1)
+	})
+
+	t.Run("RenameAndDelete", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		n := &model.Notebook{Name: "Work", OwnerID: u.ID}
+		s.Notebook().Create(context.Background(), n)
+
+		assert.NoError(t, s.Notebook().Rename(context.Background(), n.ID, "Job"))
+		n1, err := s.Notebook().Find(context.Background(), n.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Job", n1.Name)
+
+		a := model.TestArticle(t, u.ID)
+		a.NotebookID = n.ID
+		s.Article().CreateArticle(context.Background(), a)
+
+		assert.NoError(t, s.Notebook().Delete(context.Background(), n.ID))
+		_, err = s.Article().Find(context.Background(), a.ID)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		assert.EqualError(t, s.Notebook().Delete(context.Background(), n.ID), store.ErrRecordNotFound.Error())
+	})
+
+	t.Run("IDs", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		n1 := &model.Notebook{Name: "Work", OwnerID: u.ID}
+		n2 := &model.Notebook{Name: "Home", OwnerID: u.ID}
+		assert.NoError(t, s.Notebook().Create(context.Background(), n1))
+		assert.NoError(t, s.Notebook().Create(context.Background(), n2))
+		assert.Positive(t, n1.ID)
+		assert.Greater(t, n2.ID, n1.ID)
+		assert.False(t, n1.CreatedAt.IsZero())
+
+		assert.NoError(t, s.Notebook().Delete(context.Background(), n1.ID))
+
+		n3 := &model.Notebook{Name: "Travel", OwnerID: u.ID}
+		assert.NoError(t, s.Notebook().Create(context.Background(), n3))
+		assert.Greater(t, n3.ID, n2.ID)
+
+		ns, err := s.Notebook().FindByOwner(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Len(t, ns, 2)
+		assert.Equal(t, n2.ID, ns[0].ID)
+		assert.Equal(t, "Travel", ns[1].Name)
+
+		_, err = s.Notebook().Find(context.Background(), n1.ID)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+		assert.EqualError(t, s.Notebook().Rename(context.Background(), n1.ID, "Gone"), store.ErrRecordNotFound.Error())
+	})
+}
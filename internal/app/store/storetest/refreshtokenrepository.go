@@ -0,0 +1,52 @@
+package storetest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"testing"
+	"time"
+)
+
+// RunRefreshTokenRepositoryTests checks the behavior every
+// store.RefreshTokenRepository has to share.
+func RunRefreshTokenRepositoryTests(t *testing.T, newStore Factory) {
+	t.Run("CreateAndRevoke", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		rt := &model.RefreshToken{UserID: u.ID, TokenHash: "hash-1", FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+		assert.NoError(t, s.RefreshToken().Create(context.Background(), rt))
+		assert.Positive(t, rt.ID)
+		assert.False(t, rt.CreatedAt.IsZero())
+
+		rt1, err := s.RefreshToken().FindByHash(context.Background(), "hash-1")
+		assert.NoError(t, err)
+		assert.Equal(t, rt.ID, rt1.ID)
+		assert.Equal(t, u.ID, rt1.UserID)
+		assert.Equal(t, "family", rt1.FamilyID)
+		assert.False(t, rt1.IsRevoked())
+
+		assert.NoError(t, s.RefreshToken().Revoke(context.Background(), rt.ID))
+		assert.EqualError(t, s.RefreshToken().Revoke(context.Background(), rt.ID), store.ErrRecordNotFound.Error())
+
+		rt1, err = s.RefreshToken().FindByHash(context.Background(), "hash-1")
+		assert.NoError(t, err)
+		assert.True(t, rt1.IsRevoked())
+
+		rt2 := &model.RefreshToken{UserID: u.ID, TokenHash: "hash-2", FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+		assert.NoError(t, s.RefreshToken().Create(context.Background(), rt2))
+		assert.Greater(t, rt2.ID, rt.ID)
+		assert.NoError(t, s.RefreshToken().RevokeFamily(context.Background(), "family"))
+
+		rt2, err = s.RefreshToken().FindByHash(context.Background(), "hash-2")
+		assert.NoError(t, err)
+		assert.True(t, rt2.IsRevoked())
+
+		_, err = s.RefreshToken().FindByHash(context.Background(), "missing")
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+}
@@ -0,0 +1,86 @@
+package storetest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"testing"
+)
+
+// RunRevisionRepositoryTests checks the behavior every
+// store.RevisionRepository has to share.
+func RunRevisionRepositoryTests(t *testing.T, newStore Factory) {
+	t.Run("Create", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+
+		rev1 := model.NewRevision(a, u.ID)
+		assert.NoError(t, s.Revision().Create(context.Background(), rev1))
+		assert.Equal(t, 1, rev1.Number)
+
+		a.Text = "Changed"
+		rev2 := model.NewRevision(a, u.ID)
+		assert.NoError(t, s.Revision().Create(context.Background(), rev2))
+		assert.Equal(t, 2, rev2.Number)
+
+		revs, err := s.Revision().FindByArticle(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revs, 2)
+		assert.Equal(t, "Changed", revs[1].Text)
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+		s.Revision().Create(context.Background(), model.NewRevision(a, u.ID))
+
+		rev, err := s.Revision().Find(context.Background(), a.ID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, a.Heading, rev.Heading)
+
+		_, err = s.Revision().Find(context.Background(), a.ID, 2)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+
+		for _, text := range []string{"one", "two", "three"} {
+			a.Text = text
+			assert.NoError(t, s.Revision().Create(context.Background(), model.NewRevision(a, u.ID)))
+		}
+
+		revs, err := s.Revision().FindByArticle(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revs, 3)
+		for i, rev := range revs {
+			assert.Equal(t, i + 1, rev.Number)
+			assert.Equal(t, a.ID, rev.ArticleID)
+			assert.Equal(t, u.ID, rev.AuthorID)
+			assert.False(t, rev.CreatedAt.IsZero())
+		}
+		assert.Equal(t, "three", revs[2].Text)
+		assert.Less(t, revs[0].ID, revs[2].ID)
+
+		revs, err = s.Revision().FindByArticle(context.Background(), a.ID + 1)
+		assert.NoError(t, err)
+		assert.Len(t, revs, 0)
+	})
+}
@@ -0,0 +1,80 @@
+// Package storetest is a conformance suite for store.Store implementations.
+// Every backend runs it, so that handler tests against teststore see what
+// production sees: the same ids, errors, ordering and populated fields.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"testing"
+)
+
+// Factory returns an empty store for a single test. Anything that has to be
+// cleaned up afterwards is registered with t.Cleanup.
+type Factory func(t *testing.T) store.Store
+
+// RunAll runs every part of the suite.
+func RunAll(t *testing.T, newStore Factory) {
+	t.Run("User", func(t *testing.T) { RunUserRepositoryTests(t, newStore) })
+	t.Run("Article", func(t *testing.T) { RunArticleRepositoryTests(t, newStore) })
+	t.Run("RefreshToken", func(t *testing.T) { RunRefreshTokenRepositoryTests(t, newStore) })
+	t.Run("Notebook", func(t *testing.T) { RunNotebookRepositoryTests(t, newStore) })
+	t.Run("Revision", func(t *testing.T) { RunRevisionRepositoryTests(t, newStore) })
+	t.Run("Tag", func(t *testing.T) { RunTagRepositoryTests(t, newStore) })
+	t.Run("WithTx", func(t *testing.T) { RunWithTxTests(t, newStore) })
+	t.Run("Ping", func(t *testing.T) { RunPingTests(t, newStore) })
+}
+
+// RunWithTxTests checks that Store.WithTx commits and rolls back.
+func RunWithTxTests(t *testing.T, newStore Factory) {
+	s := newStore(t)
+	u := model.TestUser(t)
+	s.User().Create(context.Background(), u)
+
+	errAbort := errors.New("abort")
+	err := s.WithTx(context.Background(), func(tx store.Store) error {
+		if err := tx.Notebook().Create(context.Background(), &model.Notebook{Name: "Rolled back", OwnerID: u.ID}); err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+	assert.Equal(t, errAbort, err)
+
+	assert.Panics(t, func() {
+		s.WithTx(context.Background(), func(tx store.Store) error {
+			tx.Notebook().Create(context.Background(), &model.Notebook{Name: "Panicked", OwnerID: u.ID})
+			panic("boom")
+		})
+	})
+
+	ns, err := s.Notebook().FindByOwner(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, ns, 0)
+
+	assert.NoError(t, s.WithTx(context.Background(), func(tx store.Store) error {
+		return tx.Notebook().Create(context.Background(), &model.Notebook{Name: "Committed", OwnerID: u.ID})
+	}))
+
+	ns, err = s.Notebook().FindByOwner(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, ns, 1)
+}
+
+// RunPingTests checks that Store.Ping answers inside and outside of a
+// transaction and gives up with its context.
+func RunPingTests(t *testing.T, newStore Factory) {
+	s := newStore(t)
+	assert.NoError(t, s.Ping(context.Background()))
+
+	assert.NoError(t, s.WithTx(context.Background(), func(tx store.Store) error {
+		return tx.Ping(context.Background())
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, s.Ping(ctx))
+}
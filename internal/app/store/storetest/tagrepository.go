@@ -0,0 +1,84 @@
+package storetest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"testing"
+)
+
+// RunTagRepositoryTests checks the behavior every store.TagRepository has
+// to share.
+func RunTagRepositoryTests(t *testing.T, newStore Factory) {
+	t.Run("SetArticleTags", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+
+		assert.NoError(t, s.Tag().SetArticleTags(context.Background(), a.ID, u.ID, []string{"Work", "home", "work"}))
+		a1, err := s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"home", "work"}, a1.Tags)
+
+		assert.NoError(t, s.Tag().SetArticleTags(context.Background(), a.ID, u.ID, []string{"home"}))
+		tags, err := s.Tag().FindByOwner(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Len(t, tags, 2)
+		assert.Equal(t, "home", tags[0].Name)
+		assert.Equal(t, 1, tags[0].ArticleCount)
+		assert.Equal(t, 0, tags[1].ArticleCount)
+
+		ars, _, err := s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{All: true}, Tags: []string{"home", "work"}, TagMode: model.TagModeAny})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 1)
+
+		ars, _, err = s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{All: true}, Tags: []string{"home", "work"}})
+		assert.NoError(t, err)
+		assert.Len(t, ars, 0)
+
+		assert.Error(t, s.Tag().SetArticleTags(context.Background(), a.ID, u.ID, []string{""}))
+	})
+
+	t.Run("RenameAndMerge", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		a := model.TestArticle(t, u.ID)
+		s.Article().CreateArticle(context.Background(), a)
+		s.Tag().SetArticleTags(context.Background(), a.ID, u.ID, []string{"job", "work"})
+
+		tags, _ := s.Tag().FindByOwner(context.Background(), u.ID)
+		job, work := tags[0], tags[1]
+
+		assert.EqualError(t, s.Tag().Rename(context.Background(), job.ID, "Work"), store.ErrAlreadyExists.Error())
+		assert.EqualError(t, s.Tag().Rename(context.Background(), work.ID + 100, "career"), store.ErrRecordNotFound.Error())
+
+		assert.NoError(t, s.Tag().Merge(context.Background(), job.ID, work.ID))
+		_, err := s.Tag().Find(context.Background(), job.ID)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+		// Both change the tags shown on the article, so both bump its
+		// version.
+		merged, err := s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, a.Version + 1, merged.Version)
+
+		assert.NoError(t, s.Tag().Rename(context.Background(), work.ID, "Career"))
+		t1, err := s.Tag().Find(context.Background(), work.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "career", t1.Name)
+		assert.Equal(t, 1, t1.ArticleCount)
+
+		renamed, err := s.Article().Find(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, merged.Version + 1, renamed.Version)
+		assert.Equal(t, []string{"career"}, renamed.Tags)
+	})
+}
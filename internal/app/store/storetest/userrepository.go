@@ -0,0 +1,98 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"testing"
+)
+
+// RunUserRepositoryTests checks the behavior every store.UserRepository
+// has to share.
+func RunUserRepositoryTests(t *testing.T, newStore Factory) {
+	t.Run("Create", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		assert.NoError(t, s.User().Create(context.Background(), u))
+		assert.NotNil(t, u.ID)
+	})
+
+	t.Run("FindByEmail", func(t *testing.T) {
+		s := newStore(t)
+
+		user1 := model.TestUser(t)
+		s.User().Create(context.Background(), user1)
+		user2, err := s.User().FindByEmail(context.Background(), user1.Email)
+		assert.NoError(t, err)
+		assert.NotNil(t, user2)
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		s := newStore(t)
+
+		user1 := model.TestUser(t)
+		s.User().Create(context.Background(), user1)
+		user2, err := s.User().Find(context.Background(), user1.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user1.Email, user2.Email)
+		assert.Equal(t, model.DefaultRole, user2.Role)
+
+		_, err = s.User().Find(context.Background(), user1.ID + 1)
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+
+	t.Run("IDs", func(t *testing.T) {
+		s := newStore(t)
+
+		u1 := model.TestUser(t)
+		u2 := &model.User{Name: "Other", Email: "other@example.org", Password: "password"}
+		assert.NoError(t, s.User().Create(context.Background(), u1))
+		assert.NoError(t, s.User().Create(context.Background(), u2))
+		assert.Positive(t, u1.ID)
+		assert.Greater(t, u2.ID, u1.ID)
+	})
+
+	t.Run("FindByEmailFields", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		s.User().Create(context.Background(), u)
+
+		u1, err := s.User().FindByEmail(context.Background(), u.Email)
+		assert.NoError(t, err)
+		assert.Equal(t, u.ID, u1.ID)
+		assert.Equal(t, u.Name, u1.Name)
+		assert.Equal(t, u.Role, u1.Role)
+		assert.NotEmpty(t, u1.EncryptedPassword)
+
+		_, err = s.User().FindByEmail(context.Background(), "nobody@example.org")
+		assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	})
+	t.Run("Unique", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		assert.NoError(t, s.User().Create(context.Background(), u))
+
+		sameEmail := &model.User{Name: "Other", Email: u.Email, Password: "password"}
+		assert.Equal(t, store.ErrAlreadyExists, s.User().Create(context.Background(), sameEmail))
+
+		sameName := &model.User{Name: u.Name, Email: "other@example.org", Password: "password"}
+		assert.Equal(t, store.ErrAlreadyExists, s.User().Create(context.Background(), sameName))
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		s := newStore(t)
+
+		u := model.TestUser(t)
+		u.Email = "invalid"
+
+		var invalid *store.ValidationError
+		assert.True(t, errors.As(s.User().Create(context.Background(), u), &invalid))
+		assert.Contains(t, invalid.Fields, "email")
+		assert.NotContains(t, invalid.Fields, "name")
+	})
+}
@@ -1,9 +1,11 @@
 package teststore
 
 import (
-	"errors"
+	"context"
 	"rest_api/internal/app/model"
+	"rest_api/internal/app/search"
 	"rest_api/internal/app/store"
+	"sort"
 	"time"
 )
 
@@ -11,70 +13,580 @@ type ArticleRepository struct {
 	store *Store
 }
 
-func (ar *ArticleRepository) CreateArticle(article *model.Article) error {
-	article.Date = time.Now().String()
-	ar.store.articles = append(ar.store.articles, article)
+func (ar *ArticleRepository) CreateArticle(ctx context.Context, article *model.Article) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := article.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	defer ar.store.lock()()
 
-	article.ID = -1
+	u := ar.store.user(article.AuthorID)
+	if u == nil {
+		return store.ErrInvalidReference
+	}
 
-	for key, value := range ar.store.articles {
-		if value.Heading == article.Heading {
-			article.ID = key
+	if article.NotebookID == 0 {
+		n, err := ar.store.notebookRepository.findOrCreateDefault(article.AuthorID)
+		if err != nil {
+			return err
 		}
+
+		article.NotebookID = n.ID
+	} else if _, ok := ar.store.notebooks[article.NotebookID]; !ok {
+		return store.ErrInvalidReference
 	}
 
-	if article.ID == -1 {
-		return store.ErrCreate
+	article.BeforeCreate()
+	article.Date = time.Now().UTC().Truncate(24 * time.Hour).Format(time.RFC3339)
+	article.Version = 1
+	article.ID = ar.store.nextID("articles")
+
+	stored := copyArticle(article)
+	stored.AuthorName = u.Name
+	stored.Tags = nil
+	ar.store.articles[article.ID] = stored
+
+	return nil
+}
+
+func (ar *ArticleRepository) Find(ctx context.Context, id int) (*model.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer ar.store.lock()()
+
+	a := ar.live(id)
+	if a == nil {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return ar.copyOf(a), nil
+}
+
+func (ar *ArticleRepository) FindByHeading(ctx context.Context, header string, aud model.Audience) (*model.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer ar.store.lock()()
+
+	for _, value := range ar.active() {
+		if value.Heading == header && readable(aud, value) {
+			return ar.copyOf(value), nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+func (ar *ArticleRepository) DeleteArticle(ctx context.Context, id int, version int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	defer ar.store.lock()()
+
+	a := ar.live(id)
+	if a == nil {
+		return "", store.ErrRecordNotFound
 	}
 
+	if a.Version != version {
+		return "", store.ErrEditConflict
+	}
+
+	now := time.Now().UTC()
+	a.DeletedAt = &now
+	a.Version++
+
+	return a.Heading, nil
+}
+
+func (ar *ArticleRepository) ChangeArticleById(ctx context.Context, article *model.Article) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := article.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	defer ar.store.lock()()
+
+	stored := ar.live(article.ID)
+	if stored == nil {
+		return store.ErrRecordNotFound
+	}
+
+	if stored.Version != article.Version {
+		return store.ErrEditConflict
+	}
+
+	stored.Heading = article.Heading
+	stored.Text = article.Text
+	stored.Version++
+	article.Version = stored.Version
+
 	return nil
 }
 
-func (ar *ArticleRepository) FindByHeading(header string) (*model.Article, error) {
+func (ar *ArticleRepository) List(ctx context.Context, f *model.ArticleFilter) ([]*model.Article, *model.ArticleCursor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	defer ar.store.lock()()
+
+	key, desc, err := f.SortKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sortKey := func(a *model.Article) string {
+		if key == model.SortHeading {
+			return a.Heading
+		}
+
+		return a.Date
+	}
+
+	// less reports whether a comes before the position (k, id) in the
+	// requested order.
+	less := func(a *model.Article, k string, id int) bool {
+		ak := sortKey(a)
+		if ak == k {
+			return a.ID != id && (a.ID < id) != desc
+		}
+
+		return (ak < k) != desc
+	}
+
+	ars := make([]*model.Article, 0)
+
+	for _, value := range ar.active() {
+		if f.AuthorID != 0 && value.AuthorID != f.AuthorID {
+			continue
+		}
+
+		if f.NotebookID != 0 && value.NotebookID != f.NotebookID {
+			continue
+		}
+
+		if f.Status != "" && value.Status != f.Status {
+			continue
+		}
+
+		if !visible(f.Audience, value) {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, value.Date)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if (!f.From.IsZero() && date.Before(f.From)) || (!f.To.IsZero() && date.After(f.To)) {
+			continue
+		}
+
+		if !hasTags(ar.tags().names(value.ID), f.Tags, f.TagMode == model.TagModeAny) {
+			continue
+		}
+
+		if f.Cursor != nil && !less(&model.Article{ID: f.Cursor.ID, Heading: f.Cursor.Key, Date: f.Cursor.Key}, sortKey(value), value.ID) {
+			continue
+		}
+
+		ars = append(ars, ar.copyOf(value))
+	}
+
+	sort.Slice(ars, func(i, j int) bool {
+		return less(ars[i], sortKey(ars[j]), ars[j].ID)
+	})
+
+	limit := f.PageSize()
+	if len(ars) <= limit {
+		return ars, nil, nil
+	}
+
+	ars = ars[:limit]
+
+	return ars, model.NewArticleCursor(f.Sort, ars[len(ars) - 1]), nil
+}
+
+func (ar *ArticleRepository) MoveToNotebook(ctx context.Context, article *model.Article) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer ar.store.lock()()
+
+	stored := ar.live(article.ID)
+	if stored == nil {
+		return store.ErrRecordNotFound
+	}
+
+	if stored.Version != article.Version {
+		return store.ErrEditConflict
+	}
+
+	if _, ok := ar.store.notebooks[article.NotebookID]; !ok {
+		return store.ErrInvalidReference
+	}
+
+	stored.NotebookID = article.NotebookID
+	stored.Version++
+	article.Version = stored.Version
+
+	return nil
+}
+
+func (ar *ArticleRepository) MoveAllToNotebook(ctx context.Context, fromID int, toID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer ar.store.lock()()
+
+	if _, ok := ar.store.notebooks[toID]; !ok {
+		return store.ErrInvalidReference
+	}
+
+	for _, value := range ar.active() {
+		if value.NotebookID == fromID {
+			value.NotebookID = toID
+			value.Version++
+		}
+	}
+
+	return nil
+}
+
+func (ar *ArticleRepository) MoveTrashToNotebook(ctx context.Context, fromID int, toID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer ar.store.lock()()
+
+	if _, ok := ar.store.notebooks[toID]; !ok {
+		return store.ErrInvalidReference
+	}
+
 	for _, value := range ar.store.articles {
-		if value.Heading == header {
-			return value, nil
+		if value.NotebookID == fromID && value.DeletedAt != nil {
+			value.NotebookID = toID
+			value.Version++
 		}
 	}
 
-	return nil, errors.New("article not found")
+	return nil
 }
 
-func (ar *ArticleRepository) ShowAllArticles() ([]*model.Article, error) {
+func (ar *ArticleRepository) TrashAllInNotebook(ctx context.Context, notebookID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer ar.store.lock()()
+
+	now := time.Now().UTC()
+	for _, value := range ar.active() {
+		if value.NotebookID == notebookID {
+			deletedAt := now
+			value.DeletedAt = &deletedAt
+			value.Version++
+		}
+	}
+
+	return nil
+}
+
+func (ar *ArticleRepository) UpdateStatus(ctx context.Context, article *model.Article) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer ar.store.lock()()
+
+	stored := ar.live(article.ID)
+	if stored == nil {
+		return store.ErrRecordNotFound
+	}
+
+	if stored.Version != article.Version {
+		return store.ErrEditConflict
+	}
+
+	stored.Status = article.Status
+	stored.Visibility = article.Visibility
+	stored.PublishAt = copyTime(article.PublishAt)
+	stored.PublishedAt = copyTime(article.PublishedAt)
+	stored.Version++
+	article.Version = stored.Version
+
+	return nil
+}
+
+func (ar *ArticleRepository) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer ar.store.lock()()
+
+	n := 0
+
+	for _, value := range ar.active() {
+		if value.Status == model.StatusDraft && value.PublishAt != nil && !value.PublishAt.After(now) {
+			value.Status = model.StatusPublished
+			value.PublishedAt = value.PublishAt
+			value.PublishAt = nil
+			value.Version++
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (ar *ArticleRepository) ListTrash(ctx context.Context, authorID int) ([]*model.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer ar.store.lock()()
+
 	ars := make([]*model.Article, 0)
 
 	for _, value := range ar.store.articles {
-		ars = append(ars, value)
+		if value.DeletedAt != nil && value.AuthorID == authorID {
+			ars = append(ars, ar.copyOf(value))
+		}
 	}
 
+	sort.SliceStable(ars, func(i, j int) bool {
+		if !ars[i].DeletedAt.Equal(*ars[j].DeletedAt) {
+			return ars[i].DeletedAt.After(*ars[j].DeletedAt)
+		}
+
+		return ars[i].ID > ars[j].ID
+	})
+
 	return ars, nil
 }
 
-func (ar *ArticleRepository) DeleteArticle(id int) (string, error) {
-	if len(ar.store.articles) < id + 1 {
-		return "", errors.New("article not found")
+func (ar *ArticleRepository) FindTrashed(ctx context.Context, id int) (*model.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	header := ar.store.articles[id].Heading
-	copy(ar.store.articles[id:], ar.store.articles[id + 1:])
-	ar.store.articles[len(ar.store.articles) - 1] = nil
-	ar.store.articles = ar.store.articles[:len(ar.store.articles) - 1]
+	defer ar.store.lock()()
+
+	a := ar.store.article(id)
+	if a == nil || a.DeletedAt == nil {
+		return nil, store.ErrRecordNotFound
+	}
 
-	return header, nil
+	return ar.copyOf(a), nil
 }
 
-func (ar *ArticleRepository) ChangeArticleById(article *model.Article) error {
-	if len(ar.store.articles) < article.ID + 1 {
-		return errors.New("article not found")
+func (ar *ArticleRepository) Restore(ctx context.Context, article *model.Article) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	ar.store.articles[article.ID].Heading = article.Heading
-	ar.store.articles[article.ID].Text = article.Text
+	defer ar.store.lock()()
 
-	if ar.store.articles[article.ID].Heading != article.Heading ||
-		ar.store.articles[article.ID].Text != article.Text {
-		return errors.New("change went with wrong")
+	stored := ar.store.article(article.ID)
+	if stored == nil || stored.DeletedAt == nil {
+		return store.ErrRecordNotFound
 	}
 
+	stored.DeletedAt = nil
+	stored.Version++
+	article.DeletedAt = nil
+	article.Version = stored.Version
+
 	return nil
-}
\ No newline at end of file
+}
+
+func (ar *ArticleRepository) EmptyTrash(ctx context.Context, authorID int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer ar.store.lock()()
+
+	return ar.purge(func(a *model.Article) bool {
+		return a.AuthorID == authorID
+	}), nil
+}
+
+func (ar *ArticleRepository) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer ar.store.lock()()
+
+	return ar.purge(func(a *model.Article) bool {
+		return a.DeletedAt.Before(before)
+	}), nil
+}
+
+// purge removes trashed articles that match.
+func (ar *ArticleRepository) purge(match func(*model.Article) bool) int {
+	n := 0
+
+	for _, value := range ar.store.sortedArticles() {
+		if value.DeletedAt != nil && match(value) {
+			ar.store.deleteArticle(value.ID)
+			n++
+		}
+	}
+
+	return n
+}
+
+// live returns the article with the given id unless it is trashed or gone.
+func (ar *ArticleRepository) live(id int) *model.Article {
+	a := ar.store.article(id)
+	if a == nil || a.DeletedAt != nil {
+		return nil
+	}
+
+	return a
+}
+
+// active returns all articles that are not in the trash, in id order.
+func (ar *ArticleRepository) active() []*model.Article {
+	ars := make([]*model.Article, 0, len(ar.store.articles))
+	for _, value := range ar.store.sortedArticles() {
+		if value.DeletedAt == nil {
+			ars = append(ars, value)
+		}
+	}
+
+	return ars
+}
+
+func (ar *ArticleRepository) Search(ctx context.Context, f *model.SearchFilter) ([]*model.SearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer ar.store.lock()()
+
+	q, err := search.Parse(f.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*model.SearchResult, 0)
+
+	for _, value := range ar.active() {
+		if f.AuthorID != 0 && value.AuthorID != f.AuthorID {
+			continue
+		}
+
+		if !visible(f.Audience, value) {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, value.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		if (!f.From.IsZero() && date.Before(f.From)) || (!f.To.IsZero() && date.After(f.To)) {
+			continue
+		}
+
+		rank, ok := q.Rank(value.Heading, value.Text)
+		if !ok {
+			continue
+		}
+
+		results = append(results, &model.SearchResult{
+			Article: ar.copyOf(value),
+			Rank: rank,
+			HeadingHighlight: q.Highlight(value.Heading, false),
+			Snippet: q.Highlight(value.Text, true),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+
+		return results[i].ID > results[j].ID
+	})
+
+	if f.Limit > 0 && len(results) > f.Limit {
+		results = results[:f.Limit]
+	}
+
+	return results, nil
+}
+
+// copyOf returns what a read hands out: a copy of the stored article with
+// its tags filled in.
+func (ar *ArticleRepository) copyOf(a *model.Article) *model.Article {
+	c := copyArticle(a)
+	c.Tags = ar.tags().names(a.ID)
+
+	return c
+}
+
+func (ar *ArticleRepository) tags() *TagRepository {
+	return ar.store.tagRepository
+}
+
+func visible(aud model.Audience, a *model.Article) bool {
+	if aud.ViewerID != 0 && a.AuthorID == aud.ViewerID {
+		return true
+	}
+
+	return a.IsListed() || (aud.All && a.Visibility != model.VisibilityPrivate)
+}
+
+// readable reports whether aud may read a by its id or heading, which unlike
+// a listing includes unlisted articles.
+func readable(aud model.Audience, a *model.Article) bool {
+	if aud.ViewerID != 0 && a.AuthorID == aud.ViewerID {
+		return true
+	}
+
+	return a.IsReadable() || (aud.All && a.Visibility != model.VisibilityPrivate)
+}
+
+// hasTags reports whether tags contain all of wanted, or any of them when any
+// is set. An empty wanted list matches everything.
+func hasTags(tags []string, wanted []string, any bool) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+
+	found := 0
+	for _, w := range wanted {
+		for _, t := range tags {
+			if t == w {
+				found++
+				break
+			}
+		}
+	}
+
+	if any {
+		return found > 0
+	}
+
+	return found == len(wanted)
+}
@@ -0,0 +1,168 @@
+package teststore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"sort"
+	"time"
+)
+
+type NotebookRepository struct {
+	store *Store
+}
+
+func (r *NotebookRepository) Create(ctx context.Context, n *model.Notebook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := n.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	// Default notebooks only come from FindOrCreateDefault.
+	n.IsDefault = false
+
+	defer r.store.lock()()
+
+	return r.create(n)
+}
+
+func (r *NotebookRepository) Find(ctx context.Context, id int) (*model.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	n, ok := r.store.notebooks[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *n
+
+	return &c, nil
+}
+
+func (r *NotebookRepository) FindByOwner(ctx context.Context, ownerID int) ([]*model.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	ns := make([]*model.Notebook, 0)
+
+	for _, n := range r.store.notebooks {
+		if n.OwnerID == ownerID {
+			c := *n
+			ns = append(ns, &c)
+		}
+	}
+
+	sort.Slice(ns, func(i, j int) bool {
+		return ns[i].ID < ns[j].ID
+	})
+
+	return ns, nil
+}
+
+func (r *NotebookRepository) FindOrCreateDefault(ctx context.Context, ownerID int) (*model.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	n, err := r.findOrCreateDefault(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := *n
+
+	return &c, nil
+}
+
+func (r *NotebookRepository) Rename(ctx context.Context, id int, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	renamed := &model.Notebook{Name: name}
+	if err := renamed.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
+	defer r.store.lock()()
+
+	n, ok := r.store.notebooks[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	n.Name = name
+
+	return nil
+}
+
+func (r *NotebookRepository) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock()()
+
+	if _, ok := r.store.notebooks[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.store.notebooks, id)
+
+	for _, a := range r.store.sortedArticles() {
+		if a.NotebookID == id {
+			r.store.deleteArticle(a.ID)
+		}
+	}
+
+	return nil
+}
+
+// create stores a copy of n. The caller holds the store's lock.
+func (r *NotebookRepository) create(n *model.Notebook) error {
+	if r.store.user(n.OwnerID) == nil {
+		return store.ErrInvalidReference
+	}
+
+	n.ID = r.store.nextID("notebooks")
+	n.CreatedAt = time.Now()
+
+	stored := *n
+	r.store.notebooks[n.ID] = &stored
+
+	return nil
+}
+
+// findOrCreateDefault returns the stored default notebook of the owner. The
+// caller holds the store's lock.
+func (r *NotebookRepository) findOrCreateDefault(ownerID int) (*model.Notebook, error) {
+	for _, n := range r.store.notebooks {
+		if n.OwnerID == ownerID && n.IsDefault {
+			return n, nil
+		}
+	}
+
+	n := &model.Notebook{
+		Name: model.DefaultNotebookName,
+		OwnerID: ownerID,
+		IsDefault: true,
+	}
+
+	if err := r.create(n); err != nil {
+		return nil, err
+	}
+
+	return r.store.notebooks[n.ID], nil
+}
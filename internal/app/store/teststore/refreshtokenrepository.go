@@ -0,0 +1,89 @@
+package teststore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, t *model.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock()()
+
+	if r.store.user(t.UserID) == nil {
+		return store.ErrInvalidReference
+	}
+
+	// refresh_tokens.token_hash is unique.
+	for _, value := range r.store.refreshTokens {
+		if value.TokenHash == t.TokenHash {
+			return store.ErrAlreadyExists
+		}
+	}
+
+	t.ID = r.store.nextID("refresh_tokens")
+	t.CreatedAt = time.Now()
+	r.store.refreshTokens[t.ID] = copyRefreshToken(t)
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	for _, t := range r.store.refreshTokens {
+		if t.TokenHash == hash {
+			return copyRefreshToken(t), nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock()()
+
+	t, ok := r.store.refreshTokens[id]
+	if !ok || t.IsRevoked() {
+		return store.ErrRecordNotFound
+	}
+
+	now := time.Now()
+	t.RevokedAt = &now
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock()()
+
+	now := time.Now()
+
+	for _, t := range r.store.refreshTokens {
+		if t.FamilyID == familyID && !t.IsRevoked() {
+			t.RevokedAt = &now
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package teststore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"time"
+)
+
+type RevisionRepository struct {
+	store *Store
+}
+
+func (r *RevisionRepository) Create(ctx context.Context, rev *model.Revision) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock()()
+
+	if r.store.article(rev.ArticleID) == nil || r.store.user(rev.AuthorID) == nil {
+		return store.ErrInvalidReference
+	}
+
+	rev.Number = 1
+	for _, value := range r.store.revisions {
+		if value.ArticleID == rev.ArticleID && value.Number >= rev.Number {
+			rev.Number = value.Number + 1
+		}
+	}
+
+	rev.ID = r.store.nextID("article_revisions")
+	rev.CreatedAt = time.Now()
+
+	// Revisions are immutable, so the store keeps its own copy.
+	stored := *rev
+	r.store.revisions = append(r.store.revisions, &stored)
+
+	return nil
+}
+
+func (r *RevisionRepository) Find(ctx context.Context, articleID int, number int) (*model.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	for _, value := range r.store.revisions {
+		if value.ArticleID == articleID && value.Number == number {
+			rev := *value
+			return &rev, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+func (r *RevisionRepository) FindByArticle(ctx context.Context, articleID int) ([]*model.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	revs := make([]*model.Revision, 0)
+
+	for _, value := range r.store.revisions {
+		if value.ArticleID == articleID {
+			rev := *value
+			revs = append(revs, &rev)
+		}
+	}
+
+	return revs, nil
+}
@@ -1,36 +1,269 @@
 package teststore
 
 import (
+	"context"
 	"rest_api/internal/app/model"
 	"rest_api/internal/app/store"
+	"sort"
+	"sync"
+	"time"
 )
 
+// Store is an in-memory store.Store. It is safe for concurrent use and
+// enforces the unique and foreign key constraints of the migrations, so that
+// it can stand in for a database in handler tests and during development.
 type Store struct {
-	users []*model.User
-	articles []*model.Article
+	*tables
+	mu *sync.Mutex
+	tx bool
 	userRepository *UserRepository
 	articleRepository *ArticleRepository
+	refreshTokenRepository *RefreshTokenRepository
+	notebookRepository *NotebookRepository
+	revisionRepository *RevisionRepository
+	tagRepository *TagRepository
+}
+
+// tables holds the records. Every record is owned by the store: repositories
+// copy what they are given and hand out copies, the way rows are copied in
+// and out of a database.
+type tables struct {
+	users map[int]*model.User
+	articles map[int]*model.Article
+	refreshTokens map[int]*model.RefreshToken
+	notebooks map[int]*model.Notebook
+	revisions []*model.Revision
+	tags map[int]*model.Tag
+	articleTags map[int][]int
+	sequences map[string]int
 }
 
 func New() *Store {
-	return &Store{
-		users: make([]*model.User, 0),
-		articles: make([]*model.Article, 0),
+	return newStore(newTables(), &sync.Mutex{}, false)
+}
+
+func newStore(t *tables, mu *sync.Mutex, tx bool) *Store {
+	s := &Store{
+		tables: t,
+		mu: mu,
+		tx: tx,
 	}
+
+	// The repositories are created up front rather than on first use, which
+	// would race between concurrent requests.
+	s.userRepository = &UserRepository{s}
+	s.articleRepository = &ArticleRepository{s}
+	s.refreshTokenRepository = &RefreshTokenRepository{s}
+	s.notebookRepository = &NotebookRepository{s}
+	s.revisionRepository = &RevisionRepository{s}
+	s.tagRepository = &TagRepository{s}
+
+	return s
 }
 
-func (s *Store) User() store.UserRepository {
-	if s.userRepository == nil {
-		s.userRepository = &UserRepository{s}
+func newTables() *tables {
+	return &tables{
+		users: make(map[int]*model.User),
+		articles: make(map[int]*model.Article),
+		refreshTokens: make(map[int]*model.RefreshToken),
+		notebooks: make(map[int]*model.Notebook),
+		revisions: make([]*model.Revision, 0),
+		tags: make(map[int]*model.Tag),
+		articleTags: make(map[int][]int),
+		sequences: make(map[string]int),
 	}
+}
 
+func (s *Store) User() store.UserRepository {
 	return s.userRepository
 }
 
 func (s *Store) Article() store.ArticleRepository {
-	if s.articleRepository == nil {
-		s.articleRepository = &ArticleRepository{s}
+	return s.articleRepository
+}
+
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	return s.refreshTokenRepository
+}
+
+func (s *Store) Notebook() store.NotebookRepository {
+	return s.notebookRepository
+}
+
+func (s *Store) Revision() store.RevisionRepository {
+	return s.revisionRepository
+}
+
+func (s *Store) Tag() store.TagRepository {
+	return s.tagRepository
+}
+
+// WithTx holds the store's lock while fn runs, so that transactions are
+// serialized and nothing else sees their writes half done. It takes a
+// snapshot beforehand and puts it back when fn fails or panics, the way a
+// rolled back transaction would leave the database. Calling WithTx on the
+// store passed to fn joins the transaction that is already running.
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	return s.articleRepository
-}
\ No newline at end of file
+	if s.tx {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.snapshot()
+
+	defer func() {
+		if p := recover(); p != nil {
+			*s.tables = *snap
+			panic(p)
+		}
+
+		if err != nil {
+			*s.tables = *snap
+		}
+	}()
+
+	return fn(newStore(s.tables, s.mu, true))
+}
+
+// Ping reports whether ctx is still alive; there is no database to reach.
+func (s *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// lock takes the store's lock for a single repository call and returns the
+// function that releases it. A transaction already holds the lock, so there
+// lock does nothing.
+func (s *Store) lock() func() {
+	if s.tx {
+		return func() {}
+	}
+
+	s.mu.Lock()
+
+	return s.mu.Unlock
+}
+
+// snapshot returns a copy of the store's data. Records are copied as well,
+// since repositories update them in place.
+func (s *Store) snapshot() *tables {
+	snap := newTables()
+
+	for id, u := range s.users {
+		c := *u
+		snap.users[id] = &c
+	}
+
+	for id, a := range s.articles {
+		snap.articles[id] = copyArticle(a)
+	}
+
+	for id, t := range s.refreshTokens {
+		snap.refreshTokens[id] = copyRefreshToken(t)
+	}
+
+	for id, n := range s.notebooks {
+		c := *n
+		snap.notebooks[id] = &c
+	}
+
+	for _, rev := range s.revisions {
+		c := *rev
+		snap.revisions = append(snap.revisions, &c)
+	}
+
+	for id, t := range s.tags {
+		c := *t
+		snap.tags[id] = &c
+	}
+
+	for id, tagIDs := range s.articleTags {
+		snap.articleTags[id] = append([]int(nil), tagIDs...)
+	}
+
+	for table, id := range s.sequences {
+		snap.sequences[table] = id
+	}
+
+	return snap
+}
+
+// nextID hands out ids. Like a database sequence it never returns the same id
+// twice, even after records are deleted.
+func (s *Store) nextID(table string) int {
+	s.sequences[table]++
+
+	return s.sequences[table]
+}
+
+// article returns the article with the given id, trashed or not.
+func (s *Store) article(id int) *model.Article {
+	return s.articles[id]
+}
+
+func (s *Store) user(id int) *model.User {
+	return s.users[id]
+}
+
+// sortedArticles returns the articles in id order, which is the order they
+// were created in.
+func (s *Store) sortedArticles() []*model.Article {
+	ars := make([]*model.Article, 0, len(s.articles))
+	for _, a := range s.articles {
+		ars = append(ars, a)
+	}
+
+	sort.Slice(ars, func(i, j int) bool {
+		return ars[i].ID < ars[j].ID
+	})
+
+	return ars
+}
+
+// deleteArticle removes an article along with the rows that reference it, as
+// the on delete cascade clauses of the schema do.
+func (s *Store) deleteArticle(id int) {
+	delete(s.articles, id)
+	delete(s.articleTags, id)
+
+	revs := s.revisions[:0]
+	for _, rev := range s.revisions {
+		if rev.ArticleID != id {
+			revs = append(revs, rev)
+		}
+	}
+
+	s.revisions = revs
+}
+
+func copyArticle(a *model.Article) *model.Article {
+	c := *a
+	c.Tags = append([]string(nil), a.Tags...)
+	c.PublishAt = copyTime(a.PublishAt)
+	c.PublishedAt = copyTime(a.PublishedAt)
+	c.DeletedAt = copyTime(a.DeletedAt)
+
+	return &c
+}
+
+func copyRefreshToken(t *model.RefreshToken) *model.RefreshToken {
+	c := *t
+	c.RevokedAt = copyTime(t.RevokedAt)
+
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+
+	return &c
+}
@@ -0,0 +1,132 @@
+package teststore_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"rest_api/internal/app/store/storetest"
+	"rest_api/internal/app/store/teststore"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	storetest.RunAll(t, func(t *testing.T) store.Store {
+		return teststore.New()
+	})
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(context.Background(), u)
+
+	const n = 20
+
+	ids := make(chan int, n)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			a := &model.Article{Heading: fmt.Sprintf("Article %d", i), Text: "text", AuthorID: u.ID}
+			if err := s.Article().CreateArticle(context.Background(), a); err != nil {
+				t.Error(err)
+				return
+			}
+
+			s.Tag().SetArticleTags(context.Background(), a.ID, u.ID, []string{"shared"})
+			s.Article().List(context.Background(), &model.ArticleFilter{Audience: model.Audience{All: true}})
+			s.WithTx(context.Background(), func(tx store.Store) error {
+				a.Text = "changed"
+				return tx.Article().ChangeArticleById(context.Background(), a)
+			})
+
+			ids <- a.ID
+		}(i)
+	}
+
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		assert.False(t, seen[id])
+		seen[id] = true
+	}
+	assert.Len(t, seen, n)
+
+	ns, err := s.Notebook().FindByOwner(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, ns, 1)
+
+	ts, err := s.Tag().FindByOwner(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, ts, 1)
+	assert.Equal(t, n, ts[0].ArticleCount)
+}
+
+func TestStore_WithTxIsolation(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(context.Background(), u)
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		s.WithTx(context.Background(), func(tx store.Store) error {
+			tx.Notebook().Create(context.Background(), &model.Notebook{Name: "Rolled back", OwnerID: u.ID})
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+
+			return fmt.Errorf("abort")
+		})
+	}()
+
+	// A read that has to wait for the transaction sees none of its writes.
+	<-started
+	ns, err := s.Notebook().FindByOwner(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, ns, 0)
+	<-done
+}
+
+func TestStore_Copies(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(context.Background(), u)
+
+	a := model.TestArticle(t, u.ID)
+	s.Article().CreateArticle(context.Background(), a)
+	a.Heading = "Changed by the caller"
+
+	a1, err := s.Article().Find(context.Background(), a.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TestArticle(t, u.ID).Heading, a1.Heading)
+
+	*a1.PublishedAt = time.Time{}
+	a1.Version++
+
+	a2, err := s.Article().Find(context.Background(), a.ID)
+	assert.NoError(t, err)
+	assert.False(t, a2.PublishedAt.IsZero())
+	assert.Equal(t, 1, a2.Version)
+
+	u1, err := s.User().Find(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, u1.Password)
+	u1.Name = "changed"
+
+	u2, err := s.User().Find(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, u.Name, u2.Name)
+}
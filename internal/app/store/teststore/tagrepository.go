@@ -0,0 +1,215 @@
+package teststore
+
+import (
+	"context"
+	"rest_api/internal/app/model"
+	"rest_api/internal/app/store"
+	"sort"
+)
+
+type TagRepository struct {
+	store *Store
+}
+
+func (r *TagRepository) Find(ctx context.Context, id int) (*model.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	t, ok := r.store.tags[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *t
+	c.ArticleCount = r.count(id)
+
+	return &c, nil
+}
+
+func (r *TagRepository) FindByOwner(ctx context.Context, ownerID int) ([]*model.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock()()
+
+	ts := make([]*model.Tag, 0)
+
+	for _, t := range r.store.tags {
+		if t.OwnerID == ownerID {
+			c := *t
+			c.ArticleCount = r.count(t.ID)
+			ts = append(ts, &c)
+		}
+	}
+
+	sort.Slice(ts, func(i, j int) bool {
+		return ts[i].Name < ts[j].Name
+	})
+
+	return ts, nil
+}
+
+func (r *TagRepository) SetArticleTags(ctx context.Context, articleID int, ownerID int, names []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	names, err := model.NormalizeTags(names)
+	if err != nil {
+		return store.NewValidationError(err)
+	}
+
+	defer r.store.lock()()
+
+	if len(names) > 0 && (r.store.article(articleID) == nil || r.store.user(ownerID) == nil) {
+		return store.ErrInvalidReference
+	}
+
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		t := r.findByName(ownerID, name)
+		if t == nil {
+			t = &model.Tag{
+				ID: r.store.nextID("tags"),
+				Name: name,
+				OwnerID: ownerID,
+			}
+			r.store.tags[t.ID] = t
+		}
+
+		ids = append(ids, t.ID)
+	}
+
+	r.store.articleTags[articleID] = ids
+
+	return nil
+}
+
+func (r *TagRepository) Rename(ctx context.Context, id int, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	names, err := model.NormalizeTags([]string{name})
+	if err != nil {
+		return store.NewValidationError(err)
+	}
+
+	defer r.store.lock()()
+
+	t, ok := r.store.tags[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if other := r.findByName(t.OwnerID, names[0]); other != nil && other.ID != id {
+		return store.ErrAlreadyExists
+	}
+
+	t.Name = names[0]
+	r.touchArticles(id)
+
+	return nil
+}
+
+func (r *TagRepository) Merge(ctx context.Context, fromID int, toID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.store.lock()()
+
+	if _, ok := r.store.tags[fromID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if _, ok := r.store.tags[toID]; !ok {
+		return store.ErrInvalidReference
+	}
+
+	r.touchArticles(fromID)
+
+	for articleID, ids := range r.store.articleTags {
+		merged := make([]int, 0, len(ids))
+		seen := false
+
+		for _, id := range ids {
+			if id == fromID || id == toID {
+				if seen {
+					continue
+				}
+
+				seen = true
+				id = toID
+			}
+
+			merged = append(merged, id)
+		}
+
+		r.store.articleTags[articleID] = merged
+	}
+
+	delete(r.store.tags, fromID)
+
+	return nil
+}
+
+// touchArticles bumps the version of every article tagged with id, since the
+// tags are part of what the version stands for. The caller holds the store's
+// lock.
+func (r *TagRepository) touchArticles(id int) {
+	for articleID, ids := range r.store.articleTags {
+		for _, tagID := range ids {
+			if tagID == id {
+				if a := r.store.article(articleID); a != nil {
+					a.Version++
+				}
+
+				break
+			}
+		}
+	}
+}
+
+func (r *TagRepository) findByName(ownerID int, name string) *model.Tag {
+	for _, t := range r.store.tags {
+		if t.OwnerID == ownerID && t.Name == name {
+			return t
+		}
+	}
+
+	return nil
+}
+
+func (r *TagRepository) count(id int) int {
+	n := 0
+	for articleID, ids := range r.store.articleTags {
+		if a := r.store.article(articleID); a == nil || a.DeletedAt != nil {
+			continue
+		}
+
+		for _, tagID := range ids {
+			if tagID == id {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// names returns the sorted tag names of an article.
+func (r *TagRepository) names(articleID int) []string {
+	var names []string
+	for _, id := range r.store.articleTags[articleID] {
+		names = append(names, r.store.tags[id].Name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
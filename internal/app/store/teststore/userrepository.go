@@ -1,6 +1,7 @@
 package teststore
 
 import (
+	"context"
 	"rest_api/internal/app/model"
 	"rest_api/internal/app/store"
 )
@@ -9,38 +10,68 @@ type UserRepository struct {
 	store *Store
 }
 
-func (ur *UserRepository) Create(user *model.User) error {
-	if err := user.Validate(); err != nil {
+func (ur *UserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
+	if err := user.Validate(); err != nil {
+		return store.NewValidationError(err)
+	}
+
 	if err := user.BeforeCreate(); err != nil {
 		return err
 	}
 
-	ur.store.users = append(ur.store.users, user)
-
-	user.ID = -1
+	defer ur.store.lock()()
 
-	for key, value := range ur.store.users {
-		if value.Email == user.Email {
-			user.ID = key
+	// users.name and users.email are unique.
+	for _, value := range ur.store.users {
+		if value.Name == user.Name || value.Email == user.Email {
+			return store.ErrAlreadyExists
 		}
 	}
 
-	if user.ID == -1 {
-		return store.ErrCreate
-	}
+	user.ID = ur.store.nextID("users")
+
+	// Only the hash is kept, as in the users table.
+	stored := *user
+	stored.Password = ""
+	ur.store.users[user.ID] = &stored
 
 	return nil
 }
 
-func (ur *UserRepository) FindByEmail(email string) (*model.User, error) {
+func (ur *UserRepository) Find(ctx context.Context, id int) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer ur.store.lock()()
+
+	u := ur.store.user(id)
+	if u == nil {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *u
+
+	return &c, nil
+}
+
+func (ur *UserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer ur.store.lock()()
+
 	for _, value := range ur.store.users {
 		if value.Email == email {
-			return value, nil
+			c := *value
+			return &c, nil
 		}
 	}
 
 	return nil, store.ErrRecordNotFound
-}
\ No newline at end of file
+}
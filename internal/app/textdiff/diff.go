@@ -0,0 +1,208 @@
+// Package textdiff produces line based unified diffs.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around a change,
+// the same as diff -u.
+const DefaultContext = 3
+
+type opKind byte
+
+const (
+	opEqual opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+	// aLine and bLine are the 0-based positions in a and b before the op.
+	aLine int
+	bLine int
+}
+
+// Unified returns the unified diff that turns a into b, or an empty string if
+// the texts have the same lines.
+func Unified(fromName string, toName string, a string, b string, context int) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	hunks := groupHunks(ops, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for _, h := range hunks {
+		writeHunk(sb, h)
+	}
+
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines computes the shortest edit script with Myers' algorithm.
+func diffLines(a []string, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	trace := make([][]int, 0)
+
+search:
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	ops := make([]op, 0, max)
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{kind: opEqual, line: a[x], aLine: x, bLine: y})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, op{kind: opInsert, line: b[y], aLine: x, bLine: y})
+			} else {
+				x--
+				ops = append(ops, op{kind: opDelete, line: a[x], aLine: x, bLine: y})
+			}
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
+
+// groupHunks splits the edit script into hunks of changes with up to context
+// unchanged lines around them. Changes closer than two contexts apart share a
+// hunk.
+func groupHunks(ops []op, context int) [][]op {
+	hunks := make([][]op, 0)
+	start, end := -1, -1
+
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+
+		from := i - context
+		if from < 0 {
+			from = 0
+		}
+
+		if start >= 0 && from > end {
+			hunks = append(hunks, ops[start:end])
+			start = -1
+		}
+
+		if start < 0 {
+			start = from
+		}
+
+		end = i + context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+	}
+
+	if start >= 0 {
+		hunks = append(hunks, ops[start:end])
+	}
+
+	return hunks
+}
+
+func writeHunk(sb *strings.Builder, h []op) {
+	aCount, bCount := 0, 0
+	for _, o := range h {
+		if o.kind != opInsert {
+			aCount++
+		}
+
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(h[0].aLine, aCount), hunkRange(h[0].bLine, bCount))
+
+	for _, o := range h {
+		sb.WriteByte(byte(o.kind))
+		sb.WriteString(o.line)
+		sb.WriteByte('\n')
+	}
+}
+
+func hunkRange(line int, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line)
+	case 1:
+		return fmt.Sprintf("%d", line+1)
+	}
+
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
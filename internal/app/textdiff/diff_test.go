@@ -0,0 +1,60 @@
+package textdiff_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"rest_api/internal/app/textdiff"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	testCases := []struct {
+		name string
+		a string
+		b string
+		expected string
+	}{
+		{
+			name: "equal",
+			a: "one\ntwo\n",
+			b: "one\ntwo",
+			expected: "",
+		},
+		{
+			name: "changed line",
+			a: "one\ntwo\nthree\n",
+			b: "one\n2\nthree\n",
+			expected: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name: "from empty",
+			a: "",
+			b: "one\ntwo\n",
+			expected: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name: "to empty",
+			a: "one\n",
+			b: "",
+			expected: "--- a\n+++ b\n@@ -1 +0,0 @@\n-one\n",
+		},
+		{
+			name: "separate hunks",
+			a: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b: "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			expected: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name: "merged hunks",
+			a: "1\n2\n3\n4\n5\n6\n7\n",
+			b: "one\n2\n3\n4\n5\n6\nseven\n",
+			expected: "--- a\n+++ b\n@@ -1,7 +1,7 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n-7\n+seven\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, textdiff.Unified("a", "b", tc.a, tc.b, textdiff.DefaultContext))
+		})
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE refresh_tokens(
+    id bigserial primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    token_hash varchar(64) not null unique,
+    family_id varchar(32) not null,
+    expires_at timestamptz not null,
+    revoked_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens(family_id);
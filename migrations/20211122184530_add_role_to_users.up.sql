@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN role varchar(16) not null default 'author';
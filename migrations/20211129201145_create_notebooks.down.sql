@@ -0,0 +1,2 @@
+ALTER TABLE articles DROP COLUMN notebook_id;
+DROP TABLE notebooks;
@@ -0,0 +1,20 @@
+CREATE TABLE notebooks(
+    id serial primary key,
+    name varchar(50) not null,
+    owner_id bigint not null references users(id) on delete cascade,
+    is_default boolean not null default false,
+    created_at timestamptz not null default now()
+);
+
+CREATE UNIQUE INDEX notebooks_owner_default_idx ON notebooks(owner_id) WHERE is_default;
+
+INSERT INTO notebooks (name, owner_id, is_default)
+SELECT 'Notes', id, true FROM users;
+
+ALTER TABLE articles ADD COLUMN notebook_id integer references notebooks(id) on delete cascade;
+
+UPDATE articles a SET notebook_id = n.id
+FROM notebooks n
+WHERE n.owner_id = a.author_id AND n.is_default;
+
+ALTER TABLE articles ALTER COLUMN notebook_id SET NOT NULL;
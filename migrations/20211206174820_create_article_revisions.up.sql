@@ -0,0 +1,13 @@
+CREATE TABLE article_revisions(
+    id serial primary key,
+    article_id integer not null references articles(id) on delete cascade,
+    revision integer not null,
+    article_header varchar(50) not null,
+    article_text text not null,
+    author_id bigint not null references users(id),
+    created_at timestamptz not null default now(),
+    unique (article_id, revision)
+);
+
+INSERT INTO article_revisions (article_id, revision, article_header, article_text, author_id)
+SELECT id, 1, article_header, article_text, author_id FROM articles;
@@ -0,0 +1 @@
+ALTER TABLE articles ADD COLUMN version integer not null default 1;
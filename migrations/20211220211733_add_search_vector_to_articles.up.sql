@@ -0,0 +1,6 @@
+ALTER TABLE articles ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', article_header), 'A') ||
+    setweight(to_tsvector('english', article_text), 'B')
+) STORED;
+
+CREATE INDEX articles_search_vector_idx ON articles USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX articles_notebook_id_idx;
+DROP INDEX articles_author_id_idx;
+DROP INDEX articles_header_id_idx;
+DROP INDEX articles_creating_date_id_idx;
@@ -0,0 +1,4 @@
+CREATE INDEX articles_creating_date_id_idx ON articles (creating_date, id);
+CREATE INDEX articles_header_id_idx ON articles (article_header, id);
+CREATE INDEX articles_author_id_idx ON articles (author_id);
+CREATE INDEX articles_notebook_id_idx ON articles (notebook_id);
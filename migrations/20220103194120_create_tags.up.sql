@@ -0,0 +1,14 @@
+CREATE TABLE tags(
+    id serial primary key,
+    name varchar(50) not null,
+    owner_id bigint not null references users(id) on delete cascade,
+    unique (owner_id, name)
+);
+
+CREATE TABLE article_tags(
+    article_id integer not null references articles(id) on delete cascade,
+    tag_id integer not null references tags(id) on delete cascade,
+    primary key (article_id, tag_id)
+);
+
+CREATE INDEX article_tags_tag_id_idx ON article_tags(tag_id);
@@ -0,0 +1,5 @@
+DROP INDEX articles_publish_at_idx;
+ALTER TABLE articles DROP COLUMN published_at;
+ALTER TABLE articles DROP COLUMN publish_at;
+ALTER TABLE articles DROP COLUMN visibility;
+ALTER TABLE articles DROP COLUMN status;
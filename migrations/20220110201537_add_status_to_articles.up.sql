@@ -0,0 +1,8 @@
+ALTER TABLE articles ADD COLUMN status varchar(16) not null default 'draft';
+ALTER TABLE articles ADD COLUMN visibility varchar(16) not null default 'public';
+ALTER TABLE articles ADD COLUMN publish_at timestamptz;
+ALTER TABLE articles ADD COLUMN published_at timestamptz;
+
+UPDATE articles SET status = 'published', published_at = creating_date;
+
+CREATE INDEX articles_publish_at_idx ON articles(publish_at) WHERE status = 'draft' AND publish_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DELETE FROM articles WHERE deleted_at IS NOT NULL;
+
+DROP INDEX articles_deleted_at_idx;
+ALTER TABLE articles DROP COLUMN deleted_at;
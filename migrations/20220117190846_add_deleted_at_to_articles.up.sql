@@ -0,0 +1,3 @@
+ALTER TABLE articles ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX articles_deleted_at_idx ON articles(deleted_at) WHERE deleted_at IS NOT NULL;
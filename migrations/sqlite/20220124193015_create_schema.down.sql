@@ -0,0 +1,7 @@
+DROP TABLE article_tags;
+DROP TABLE tags;
+DROP TABLE article_revisions;
+DROP TABLE articles;
+DROP TABLE notebooks;
+DROP TABLE refresh_tokens;
+DROP TABLE users;
@@ -0,0 +1,77 @@
+CREATE TABLE users (
+    id integer primary key autoincrement,
+    name varchar not null unique,
+    email varchar not null unique,
+    encrypted_password varchar not null,
+    role varchar(16) not null default 'author'
+);
+
+CREATE TABLE refresh_tokens (
+    id integer primary key autoincrement,
+    user_id integer not null references users(id) on delete cascade,
+    token_hash varchar(64) not null unique,
+    family_id varchar(32) not null,
+    expires_at timestamp not null,
+    revoked_at timestamp,
+    created_at timestamp not null default current_timestamp
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens(family_id);
+
+CREATE TABLE notebooks (
+    id integer primary key autoincrement,
+    name varchar(50) not null,
+    owner_id integer not null references users(id) on delete cascade,
+    is_default boolean not null default false,
+    created_at timestamp not null default current_timestamp
+);
+
+CREATE UNIQUE INDEX notebooks_owner_default_idx ON notebooks(owner_id) WHERE is_default;
+
+CREATE TABLE articles (
+    id integer primary key autoincrement,
+    article_header varchar(50) not null,
+    article_text text not null,
+    author_id integer not null references users(id),
+    notebook_id integer not null references notebooks(id) on delete cascade,
+    creating_date date not null,
+    version integer not null default 1,
+    status varchar(16) not null default 'draft',
+    visibility varchar(16) not null default 'public',
+    publish_at timestamp,
+    published_at timestamp,
+    deleted_at timestamp
+);
+
+CREATE INDEX articles_creating_date_id_idx ON articles (creating_date, id);
+CREATE INDEX articles_header_id_idx ON articles (article_header, id);
+CREATE INDEX articles_author_id_idx ON articles (author_id);
+CREATE INDEX articles_notebook_id_idx ON articles (notebook_id);
+CREATE INDEX articles_publish_at_idx ON articles (publish_at) WHERE status = 'draft' AND publish_at IS NOT NULL;
+CREATE INDEX articles_deleted_at_idx ON articles (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE article_revisions (
+    id integer primary key autoincrement,
+    article_id integer not null references articles(id) on delete cascade,
+    revision integer not null,
+    article_header varchar(50) not null,
+    article_text text not null,
+    author_id integer not null references users(id),
+    created_at timestamp not null default current_timestamp,
+    unique (article_id, revision)
+);
+
+CREATE TABLE tags (
+    id integer primary key autoincrement,
+    name varchar(50) not null,
+    owner_id integer not null references users(id) on delete cascade,
+    unique (owner_id, name)
+);
+
+CREATE TABLE article_tags (
+    article_id integer not null references articles(id) on delete cascade,
+    tag_id integer not null references tags(id) on delete cascade,
+    primary key (article_id, tag_id)
+);
+
+CREATE INDEX article_tags_tag_id_idx ON article_tags(tag_id);